@@ -2,18 +2,40 @@ package coder
 
 import (
 	"bytes"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"io"
+	"net/http"
 )
 
 const (
 	EncodingHeader    = "Protocol-Encoding"
 	ContentTypeHeader = "Content-Type"
+	// RawBodyBytesKey is the context key of the body cached before inflation
+	RawBodyBytesKey = "golib/coder/raw_body"
 )
 
-type ICoder interface {
+// MaxBodySize limits the request body read by GetRequestBody and GetRequestReader, 0 means no limit.
+var MaxBodySize int64 = 0
+
+// MaxInflatedBodySize limits a request body inflated by Content-Encoding, so a small compressed upload can't expand without bound.
+// A smaller MaxBodySize wins, 0 leaves only MaxBodySize.
+var MaxInflatedBodySize int64 = 32 << 20
+
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// ICodec is the transport agnostic part of a coder, usable for http, message queue payloads and stored values.
+// Name is the Protocol-Encoding value and ContentType the Content-Type header of the encoded data.
+type ICodec interface {
+	Name() string
+	ContentType() string
 	Unmarshal(data []byte, v interface{}) error
 	Marshal(v interface{}) ([]byte, error)
+}
+
+// ICoder is a codec with gin adapters.
+type ICoder interface {
+	ICodec
 	DecodeRequest(ctx *gin.Context, v interface{}) error
 	SendResponse(ctx *gin.Context, v interface{}) error
 }
@@ -30,13 +52,180 @@ func GetRequestBody(ctx *gin.Context) (body []byte, err error) {
 		return
 	}
 
-	body, err = io.ReadAll(ctx.Request.Body)
+	// an inflated body differs from the bytes as sent, keep those too for GetRawRequestBody
+	var raw *bytes.Buffer
+
+	if contentEncoding(ctx.Request.Header) != "" {
+		raw = new(bytes.Buffer)
+		ctx.Request.Body = &teeBody{Reader: io.TeeReader(ctx.Request.Body, raw), Closer: ctx.Request.Body}
+	}
+
+	body, err = ReadHttpBody(ctx.Request)
 
 	if err != nil {
 		return
 	}
 
-	ctx.Request.Body = io.NopCloser(bytes.NewBuffer(body))
 	ctx.Set(gin.BodyBytesKey, body)
+
+	if raw != nil {
+		ctx.Set(RawBodyBytesKey, raw.Bytes())
+	}
+
+	return
+}
+
+// GetRawRequestBody returns the request body as sent, before Content-Encoding inflation.
+// A body that was not inflated, or cached by other means than GetRequestBody, is the cached body itself.
+func GetRawRequestBody(ctx *gin.Context) ([]byte, error) {
+	body, err := GetRequestBody(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if b, ok := ctx.Get(RawBodyBytesKey); ok {
+		if bs, ok := b.([]byte); ok {
+			return bs, nil
+		}
+	}
+
+	return body, nil
+}
+
+type teeBody struct {
+	io.Reader
+	io.Closer
+}
+
+// ReadHttpBody reads the whole request body, inflated by Content-Encoding and limited by MaxBodySize.
+// The request body is replaced with the decoded bytes so it can be read again.
+func ReadHttpBody(r *http.Request) (body []byte, err error) {
+	if r.Body == nil {
+		return
+	}
+
+	reader, inflated, err := openRequestBody(r)
+
+	if err != nil {
+		return
+	}
+
+	body, err = io.ReadAll(reader)
+
+	if inflated {
+		_ = reader.Close()
+	}
+
+	if err != nil {
+		err = bodyError(err)
+		return
+	}
+
+	// the cached body is always the decoded one
+	if inflated {
+		r.Header.Del(ContentEncodingHeader)
+		r.ContentLength = int64(len(body))
+	}
+
+	r.Body = io.NopCloser(bytes.NewBuffer(body))
+	return
+}
+
+// GetRequestReader returns a reader over the request body for streaming decode.
+// The body cached by GetRequestBody is reused, otherwise the body is read and inflated directly without caching.
+func GetRequestReader(ctx *gin.Context) (io.Reader, error) {
+	if b, ok := ctx.Get(gin.BodyBytesKey); ok {
+		if bs, ok := b.([]byte); ok {
+			return bytes.NewReader(bs), nil
+		}
+	}
+
+	if ctx.Request.Body == nil {
+		return bytes.NewReader(nil), nil
+	}
+
+	reader, _, err := openRequestBody(ctx.Request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &bodyReader{reader: reader}, nil
+}
+
+// BodyLimit middleware rejects requests whose body is larger than size with 413.
+func BodyLimit(size int64) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.ContentLength > size {
+			ctx.AbortWithStatus(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if ctx.Request.Body != nil {
+			ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, size)
+		}
+
+		ctx.Next()
+	}
+}
+
+// openRequestBody limits the raw body and inflates it by Content-Encoding, the inflated body is limited by inflatedBodyLimit.
+func openRequestBody(r *http.Request) (reader io.ReadCloser, inflated bool, err error) {
+	if MaxBodySize > 0 && r.ContentLength > MaxBodySize {
+		return nil, false, ErrBodyTooLarge
+	}
+
+	reader = limitBody(r.Body, MaxBodySize)
+	decompressor, inflated, err := inflateBody(r.Header, reader)
+
+	if err != nil {
+		return nil, false, bodyError(err)
+	}
+
+	if inflated {
+		reader = limitBody(decompressor, inflatedBodyLimit())
+	}
+
+	return
+}
+
+func inflatedBodyLimit() int64 {
+	if MaxBodySize > 0 && (MaxInflatedBodySize <= 0 || MaxBodySize < MaxInflatedBodySize) {
+		return MaxBodySize
+	}
+
+	return MaxInflatedBodySize
+}
+
+func limitBody(body io.ReadCloser, size int64) io.ReadCloser {
+	if size <= 0 {
+		return body
+	}
+
+	return http.MaxBytesReader(nil, body, size)
+}
+
+func bodyError(err error) error {
+	var maxBytesError *http.MaxBytesError
+
+	if errors.As(err, &maxBytesError) {
+		return ErrBodyTooLarge
+	}
+
+	return err
+}
+
+type bodyReader struct {
+	reader io.Reader
+}
+
+func (r *bodyReader) Read(p []byte) (n int, err error) {
+	n, err = r.reader.Read(p)
+
+	if err != nil && err != io.EOF {
+		err = bodyError(err)
+	}
+
 	return
 }
@@ -0,0 +1,66 @@
+package coder
+
+import (
+	"net/http"
+	"testing"
+)
+
+type binaryUser struct {
+	Name   string   `json:"name"`
+	Age    int      `json:"age"`
+	Tags   []string `json:"tags"`
+	Avatar []byte   `json:"avatar"`
+}
+
+func TestBinaryCoder(t *testing.T) {
+	coders := map[string]ICoder{
+		ContentTypeMsgpack: MsgpackCoder,
+		ContentTypeCbor:    CborCoder,
+	}
+
+	for contentType, c := range coders {
+		user := &binaryUser{Name: "user", Age: 20, Tags: []string{"a", "b"}, Avatar: []byte{0x01, 0xff}}
+		data, err := c.Marshal(user)
+
+		if err != nil {
+			t.Fatalf("%s marshal fail. | err: %s", contentType, err)
+		}
+
+		ctx, w := newTestContext(http.MethodPost, string(data), map[string]string{ContentTypeHeader: contentType})
+
+		if DefaultRegistry.GetDecoder(ctx) != c {
+			t.Fatalf("%s registry decoder mismatch", contentType)
+		}
+
+		// decode twice, the second read must come from the cached body
+		for i := 0; i < 2; i++ {
+			result := &binaryUser{}
+
+			if err = DefaultRegistry.DecodeRequest(ctx, result); err != nil {
+				t.Fatalf("%s decode request fail. | err: %s", contentType, err)
+			}
+
+			if result.Name != user.Name || result.Age != user.Age || len(result.Tags) != 2 || string(result.Avatar) != string(user.Avatar) {
+				t.Fatalf("%s unexpected result. | result: %+v", contentType, result)
+			}
+		}
+
+		generic := make(map[string]interface{})
+
+		if err = c.Unmarshal(data, &generic); err != nil {
+			t.Fatalf("%s unmarshal generic fail. | err: %s", contentType, err)
+		}
+
+		if name, ok := generic["name"].(string); !ok || name != user.Name {
+			t.Fatalf("%s unexpected generic result. | result: %v", contentType, generic)
+		}
+
+		if err = DefaultRegistry.SendResponse(ctx, user); err != nil {
+			t.Fatalf("%s send response fail. | err: %s", contentType, err)
+		}
+
+		if w.Header().Get(ContentTypeHeader) != contentType || w.Body.String() != string(data) {
+			t.Fatalf("%s unexpected response. | header: %v", contentType, w.Header())
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package coder
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
+	"reflect"
+)
+
+const (
+	EncodingCbor    = "cbor"
+	ContentTypeCbor = "application/cbor"
+)
+
+const (
+	MimeTypeCbor = "application/cbor"
+)
+
+var CborCoder = newCborCoder()
+
+type cborCoder struct {
+	handle *codec.CborHandle
+}
+
+func (c *cborCoder) Name() string {
+	return EncodingCbor
+}
+
+func (c *cborCoder) ContentType() string {
+	return ContentTypeCbor
+}
+
+func (c *cborCoder) Unmarshal(data []byte, v interface{}) error {
+	return codec.NewDecoderBytes(data, c.handle).Decode(v)
+}
+
+func (c *cborCoder) Marshal(v interface{}) (data []byte, err error) {
+	err = codec.NewEncoderBytes(&data, c.handle).Encode(v)
+	return
+}
+
+func (c *cborCoder) DecodeRequest(ctx *gin.Context, v interface{}) error {
+	return DecodeGinRequest(ctx, c, v)
+}
+
+func (c *cborCoder) SendResponse(ctx *gin.Context, v interface{}) error {
+	return SendGinResponse(ctx, c, v)
+}
+
+func newCborCoder() *cborCoder {
+	handle := &codec.CborHandle{TimeRFC3339: true}
+	handle.MapType = reflect.TypeOf(map[string]interface{}(nil))
+
+	return &cborCoder{handle: handle}
+}
@@ -0,0 +1,237 @@
+package coder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	AcceptEncodingHeader  = "Accept-Encoding"
+	ContentEncodingHeader = "Content-Encoding"
+	VaryHeader            = "Vary"
+)
+
+const (
+	CompressGzip     = "gzip"
+	CompressDeflate  = "deflate"
+	CompressZstd     = "zstd"
+	CompressIdentity = "identity"
+)
+
+var ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+
+type CompressConfig struct {
+	Enable    bool     `toml:"enable" json:"enable" yaml:"enable"`
+	MinSize   int      `toml:"min_size" json:"min_size" yaml:"min_size"`
+	Encodings []string `toml:"encodings" json:"encodings" yaml:"encodings"`
+}
+
+// Compression controls response compression in SendResponse. Responses smaller than MinSize are sent as is.
+// Encodings is the server preference when the client accepts several with the same quality.
+var Compression = &CompressConfig{
+	Enable:    false,
+	MinSize:   1024,
+	Encodings: []string{CompressZstd, CompressGzip, CompressDeflate},
+}
+
+type compressor struct {
+	compress  func(w io.Writer, data []byte) error
+	newReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+var zstdEncoder, _ = zstd.NewWriter(nil)
+
+var gzipWriterPool = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+
+var zlibWriterPool = sync.Pool{New: func() any { return zlib.NewWriter(nil) }}
+
+var compressors = map[string]*compressor{
+	CompressGzip: {
+		compress: func(w io.Writer, data []byte) error {
+			writer := gzipWriterPool.Get().(*gzip.Writer)
+			defer gzipWriterPool.Put(writer)
+
+			writer.Reset(w)
+
+			if _, err := writer.Write(data); err != nil {
+				return err
+			}
+
+			return writer.Close()
+		},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	},
+	CompressDeflate: {
+		compress: func(w io.Writer, data []byte) error {
+			writer := zlibWriterPool.Get().(*zlib.Writer)
+			defer zlibWriterPool.Put(writer)
+
+			writer.Reset(w)
+
+			if _, err := writer.Write(data); err != nil {
+				return err
+			}
+
+			return writer.Close()
+		},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return zlib.NewReader(r)
+		},
+	},
+	CompressZstd: {
+		compress: func(w io.Writer, data []byte) error {
+			_, err := w.Write(zstdEncoder.EncodeAll(data, nil))
+			return err
+		},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+
+			if err != nil {
+				return nil, err
+			}
+
+			return decoder.IOReadCloser(), nil
+		},
+	},
+}
+
+func writeResponse(ctx *gin.Context, data []byte) error {
+	return writeBody(ctx.Writer, ctx.Request, 0, data)
+}
+
+// writeBody writes the encoded body, compressed when the client accepts one of the configured encodings.
+// The status is written after the compression headers unless it is 0.
+func writeBody(w http.ResponseWriter, r *http.Request, status int, data []byte) (err error) {
+	encoding := negotiateEncoding(w, r, len(data))
+
+	if encoding == "" {
+		if status > 0 {
+			w.WriteHeader(status)
+		}
+
+		_, err = w.Write(data)
+		return
+	}
+
+	buffer := new(bytes.Buffer)
+
+	if err = compressors[encoding].compress(buffer, data); err != nil {
+		return
+	}
+
+	w.Header().Set(ContentEncodingHeader, encoding)
+	w.Header().Set(VaryHeader, AcceptEncodingHeader)
+
+	if status > 0 {
+		w.WriteHeader(status)
+	}
+
+	_, err = w.Write(buffer.Bytes())
+	return
+}
+
+func negotiateEncoding(w http.ResponseWriter, r *http.Request, size int) string {
+	conf := Compression
+
+	if conf == nil || !conf.Enable || size < conf.MinSize {
+		return ""
+	}
+
+	// already compressed by a middleware
+	if w.Header().Get(ContentEncodingHeader) != "" {
+		return ""
+	}
+
+	if r == nil {
+		return ""
+	}
+
+	accept := r.Header.Get(AcceptEncodingHeader)
+
+	if accept == "" {
+		return ""
+	}
+
+	qualities := make(map[string]float64)
+
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		quality := 1.0
+
+		for _, param := range fields[1:] {
+			if q, found := strings.CutPrefix(strings.TrimSpace(param), "q="); found {
+				if v, err := strconv.ParseFloat(q, 64); err == nil {
+					quality = v
+				}
+			}
+		}
+
+		qualities[name] = quality
+	}
+
+	result, best := "", 0.0
+
+	for _, encoding := range conf.Encodings {
+		if _, ok := compressors[encoding]; !ok {
+			continue
+		}
+
+		quality, ok := qualities[encoding]
+
+		if !ok {
+			quality, ok = qualities["*"]
+		}
+
+		if ok && quality > best {
+			result, best = encoding, quality
+		}
+	}
+
+	return result
+}
+
+// contentEncoding returns the Content-Encoding of header, empty when the body is not encoded.
+func contentEncoding(header http.Header) string {
+	encoding := strings.ToLower(strings.TrimSpace(header.Get(ContentEncodingHeader)))
+
+	if encoding == CompressIdentity {
+		return ""
+	}
+
+	return encoding
+}
+
+// inflateBody wraps the request body with a decompressor for its Content-Encoding.
+func inflateBody(header http.Header, body io.Reader) (io.ReadCloser, bool, error) {
+	encoding := contentEncoding(header)
+
+	if encoding == "" {
+		return nil, false, nil
+	}
+
+	c, ok := compressors[encoding]
+
+	if !ok {
+		return nil, false, ErrUnsupportedEncoding
+	}
+
+	reader, err := c.newReader(body)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return reader, true, nil
+}
@@ -0,0 +1,130 @@
+package coder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCompressResponse(t *testing.T) {
+	Compression.Enable = true
+	defer func() { Compression.Enable = false }()
+
+	large := map[string]string{"data": strings.Repeat("x", 2048)}
+	cases := []struct {
+		accept string
+		data   interface{}
+		expect string
+	}{
+		{"", large, ""},
+		{"gzip", map[string]string{"data": "small"}, ""},
+		{"gzip, deflate", large, CompressGzip},
+		{"gzip;q=0.5, zstd", large, CompressZstd},
+		{"br, *", large, CompressZstd},
+		{"zstd;q=0, deflate", large, CompressDeflate},
+	}
+
+	for i, c := range cases {
+		ctx, w := newTestContext(http.MethodGet, "", map[string]string{AcceptEncodingHeader: c.accept})
+
+		if err := JsonCoder.SendResponse(ctx, c.data); err != nil {
+			t.Fatalf("case %d send response fail. | err: %s", i, err)
+		}
+
+		if encoding := w.Header().Get(ContentEncodingHeader); encoding != c.expect {
+			t.Fatalf("case %d unexpected encoding. | encoding: %s", i, encoding)
+		}
+
+		if c.expect == "" {
+			continue
+		}
+
+		reader, err := compressors[c.expect].newReader(w.Body)
+
+		if err != nil {
+			t.Fatalf("case %d new reader fail. | err: %s", i, err)
+		}
+
+		data, err := io.ReadAll(reader)
+
+		if err != nil || !bytes.Contains(data, []byte(large["data"])) {
+			t.Fatalf("case %d unexpected body. | err: %v", i, err)
+		}
+	}
+}
+
+func TestInflateRequest(t *testing.T) {
+	body := `{"name":"user","age":20}`
+
+	buffer := new(bytes.Buffer)
+	writer := gzip.NewWriter(buffer)
+	_, _ = writer.Write([]byte(body))
+	_ = writer.Close()
+
+	ctx, _ := newTestContext(http.MethodPost, buffer.String(), map[string]string{ContentEncodingHeader: CompressGzip})
+	user := &binaryUser{}
+
+	if err := JsonCoder.DecodeRequest(ctx, user); err != nil || user.Name != "user" {
+		t.Fatalf("decode gzip request fail. | err: %v", err)
+	}
+
+	if data, _ := GetRequestBody(ctx); string(data) != body {
+		t.Fatalf("unexpected cached body. | body: %s", data)
+	}
+
+	encoder, _ := zstd.NewWriter(nil)
+	ctx, _ = newTestContext(http.MethodPost, string(encoder.EncodeAll([]byte(body), nil)), map[string]string{ContentEncodingHeader: CompressZstd})
+	user = &binaryUser{}
+
+	if err := JsonCoder.DecodeRequest(ctx, user); err != nil || user.Age != 20 {
+		t.Fatalf("decode zstd request fail. | err: %v", err)
+	}
+
+	ctx, _ = newTestContext(http.MethodPost, body, map[string]string{ContentEncodingHeader: "br"})
+
+	if err := JsonCoder.DecodeRequest(ctx, user); err != ErrUnsupportedEncoding {
+		t.Fatalf("expect unsupported encoding. | err: %v", err)
+	}
+
+	// the limit applies to the inflated body
+	MaxBodySize = 128
+	defer func() { MaxBodySize = 0 }()
+
+	buffer.Reset()
+	writer.Reset(buffer)
+	_, _ = writer.Write([]byte(`"` + strings.Repeat("x", 1024) + `"`))
+	_ = writer.Close()
+
+	ctx, _ = newTestContext(http.MethodPost, buffer.String(), map[string]string{ContentEncodingHeader: CompressGzip})
+
+	if _, err := GetRequestBody(ctx); err != ErrBodyTooLarge {
+		t.Fatalf("expect body too large. | compressed: %d | err: %v", buffer.Len(), err)
+	}
+
+	// without MaxBodySize the inflated body still has its own limit
+	MaxBodySize, MaxInflatedBodySize = 0, 4096
+	defer func() { MaxInflatedBodySize = 32 << 20 }()
+
+	buffer.Reset()
+	writer.Reset(buffer)
+	_, _ = writer.Write([]byte(`"` + strings.Repeat("x", 1<<20) + `"`))
+	_ = writer.Close()
+
+	ctx, _ = newTestContext(http.MethodPost, buffer.String(), map[string]string{ContentEncodingHeader: CompressGzip})
+
+	if _, err := GetRequestBody(ctx); err != ErrBodyTooLarge {
+		t.Fatalf("expect inflated body too large. | compressed: %d | err: %v", buffer.Len(), err)
+	}
+
+	ctx, _ = newTestContext(http.MethodPost, buffer.String(), map[string]string{ContentEncodingHeader: CompressGzip})
+
+	reader, _ := GetRequestReader(ctx)
+
+	if _, err := io.ReadAll(reader); err != ErrBodyTooLarge {
+		t.Fatalf("expect inflated stream too large. | err: %v", err)
+	}
+}
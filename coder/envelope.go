@@ -0,0 +1,293 @@
+package coder
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"net/http"
+	"strconv"
+)
+
+const (
+	CodeSuccess    = 0
+	MessageSuccess = "success"
+	// MessageInternalError replaces the message of errors that are not application or validation errors
+	MessageInternalError = "internal server error"
+)
+
+// Response is the envelope written by SendSuccess and SendError for non protobuf coders.
+// Protobuf coders write a google.rpc.Status instead, its code is the grpc code and data is packed into details.
+// The business code of an error is carried in a google.rpc.ErrorInfo detail whose reason is the code.
+// Data that is not a protobuf message is packed as a google.protobuf.Value of its json form.
+type Response struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Error is an application error carrying the business code, the http status and an optional grpc code.
+type Error struct {
+	Code     int
+	Status   int
+	Message  string
+	GrpcCode codes.Code
+	Data     interface{}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("code: %d, message: %s", e.Code, e.Message)
+}
+
+func (e *Error) WithGrpcCode(code codes.Code) *Error {
+	result := *e
+	result.GrpcCode = code
+	return &result
+}
+
+func (e *Error) WithData(data interface{}) *Error {
+	result := *e
+	result.Data = data
+	return &result
+}
+
+func (e *Error) WithMessage(message string) *Error {
+	result := *e
+	result.Message = message
+	return &result
+}
+
+// GRPCStatus lets status.FromError turn the error into a grpc status.
+// The grpc code is derived from the http status when it was not set.
+func (e *Error) GRPCStatus() *status.Status {
+	code := e.GrpcCode
+
+	if code == codes.OK {
+		code = HttpStatusToGrpcCode(e.Status)
+	}
+
+	return status.New(code, e.Message)
+}
+
+func NewError(code, httpStatus int, message string) *Error {
+	return &Error{Code: code, Status: httpStatus, Message: message}
+}
+
+// FromError converts any error to an application error.
+// Unknown errors become a 500 with a generic message so internal details don't reach clients.
+func FromError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var appError *Error
+
+	if errors.As(err, &appError) {
+		return appError
+	}
+
+	if validationError, ok := IsValidationError(err); ok {
+		return &Error{
+			Code:    http.StatusBadRequest,
+			Status:  http.StatusBadRequest,
+			Message: validationError.Error(),
+			Data:    validationError,
+		}
+	}
+
+	if errors.Is(err, ErrBodyTooLarge) {
+		return NewError(http.StatusRequestEntityTooLarge, http.StatusRequestEntityTooLarge, err.Error())
+	}
+
+	if s, ok := status.FromError(err); ok {
+		httpStatus := GrpcCodeToHttpStatus(s.Code())
+		return &Error{Code: httpStatus, Status: httpStatus, Message: s.Message(), GrpcCode: s.Code()}
+	}
+
+	return NewError(http.StatusInternalServerError, http.StatusInternalServerError, MessageInternalError)
+}
+
+// SendSuccess writes data wrapped in the envelope with status 200.
+func SendSuccess(ctx *gin.Context, c ICoder, data interface{}) error {
+	return sendEnvelope(ctx, c, http.StatusOK, &Error{Code: CodeSuccess, Status: http.StatusOK, Message: MessageSuccess, Data: data})
+}
+
+// SendError writes err wrapped in the envelope with the http status of its application error.
+func SendError(ctx *gin.Context, c ICoder, err error) error {
+	appError := FromError(err)
+
+	if appError == nil {
+		appError = NewError(http.StatusInternalServerError, http.StatusInternalServerError, "unknown error")
+	}
+
+	httpStatus := appError.Status
+
+	if httpStatus == 0 {
+		httpStatus = http.StatusInternalServerError
+	}
+
+	return sendEnvelope(ctx, c, httpStatus, appError)
+}
+
+func sendEnvelope(ctx *gin.Context, c ICoder, httpStatus int, e *Error) error {
+	c = resolveEncoder(ctx, c)
+	data := e.Data
+
+	if !isProtoCoder(c) {
+		if validationError, ok := data.(*ValidationError); ok {
+			data = validationError.Fields
+		}
+
+		ctx.Status(httpStatus)
+		return c.SendResponse(ctx, &Response{Code: e.Code, Message: e.Message, Data: data})
+	}
+
+	envelope := &spb.Status{Code: int32(e.GRPCStatus().Code()), Message: e.Message}
+
+	if e.Code != CodeSuccess {
+		detail, err := anypb.New(&errdetails.ErrorInfo{Reason: strconv.Itoa(e.Code)})
+
+		if err != nil {
+			return err
+		}
+
+		envelope.Details = append(envelope.Details, detail)
+	}
+
+	if data != nil {
+		detail, err := toDetail(data)
+
+		if err != nil {
+			return err
+		}
+
+		envelope.Details = append(envelope.Details, detail)
+	}
+
+	ctx.Status(httpStatus)
+	return c.SendResponse(ctx, envelope)
+}
+
+func toDetail(data interface{}) (*anypb.Any, error) {
+	if validationError, ok := data.(*ValidationError); ok {
+		badRequest := &errdetails.BadRequest{}
+
+		for _, f := range validationError.Fields {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       f.Field,
+				Description: f.Message,
+			})
+		}
+
+		return anypb.New(badRequest)
+	}
+
+	if pb, ok := data.(proto.Message); ok {
+		return anypb.New(proto.MessageV2(pb))
+	}
+
+	b, err := JsonCoder.Marshal(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	value := &structpb.Value{}
+
+	if err = protojson.Unmarshal(b, value); err != nil {
+		return nil, err
+	}
+
+	return anypb.New(value)
+}
+
+func resolveEncoder(ctx *gin.Context, c ICoder) ICoder {
+	switch t := c.(type) {
+	case *Registry:
+		return resolveEncoder(ctx, t.GetEncoder(ctx))
+	case *validateCoder:
+		return resolveEncoder(ctx, t.ICoder)
+	case *signatureCoder:
+		return resolveEncoder(ctx, t.ICoder)
+	}
+
+	return c
+}
+
+func isProtoCoder(c ICodec) bool {
+	switch t := c.(type) {
+	case *protoCoder, *protoJsonCoder:
+		return true
+	case *ginCoder:
+		return isProtoCoder(t.ICodec)
+	}
+
+	return false
+}
+
+var httpStatusCodes = map[int]codes.Code{
+	http.StatusOK:                    codes.OK,
+	http.StatusBadRequest:            codes.InvalidArgument,
+	http.StatusUnauthorized:          codes.Unauthenticated,
+	http.StatusForbidden:             codes.PermissionDenied,
+	http.StatusNotFound:              codes.NotFound,
+	http.StatusConflict:              codes.AlreadyExists,
+	http.StatusRequestEntityTooLarge: codes.ResourceExhausted,
+	http.StatusTooManyRequests:       codes.ResourceExhausted,
+	http.StatusPreconditionFailed:    codes.FailedPrecondition,
+	http.StatusNotImplemented:        codes.Unimplemented,
+	http.StatusServiceUnavailable:    codes.Unavailable,
+	http.StatusGatewayTimeout:        codes.DeadlineExceeded,
+	499:                              codes.Canceled,
+}
+
+var grpcCodeStatus = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           499,
+	codes.Unknown:            http.StatusInternalServerError,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Internal:           http.StatusInternalServerError,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DataLoss:           http.StatusInternalServerError,
+}
+
+func HttpStatusToGrpcCode(httpStatus int) codes.Code {
+	if code, ok := httpStatusCodes[httpStatus]; ok {
+		return code
+	}
+
+	if httpStatus >= 200 && httpStatus < 300 {
+		return codes.OK
+	}
+
+	if httpStatus >= 400 && httpStatus < 500 {
+		return codes.FailedPrecondition
+	}
+
+	return codes.Internal
+}
+
+func GrpcCodeToHttpStatus(code codes.Code) int {
+	if httpStatus, ok := grpcCodeStatus[code]; ok {
+		return httpStatus
+	}
+
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,117 @@
+package coder
+
+import (
+	"errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"net/http"
+	"testing"
+)
+
+var errUserNotFound = NewError(10001, http.StatusNotFound, "user not found")
+
+func TestSendSuccess(t *testing.T) {
+	ctx, w := newTestContext(http.MethodGet, "", nil)
+
+	if err := SendSuccess(ctx, DefaultRegistry, map[string]int{"id": 1}); err != nil {
+		t.Fatalf("send success fail. | err: %s", err)
+	}
+
+	if w.Code != http.StatusOK || w.Body.String() != `{"code":0,"message":"success","data":{"id":1}}` {
+		t.Fatalf("unexpected response. | code: %d | body: %s", w.Code, w.Body.String())
+	}
+
+	ctx, w = newTestContext(http.MethodGet, "", map[string]string{AcceptHeader: MimeTypeProtobuf})
+
+	if err := SendSuccess(ctx, DefaultRegistry, wrapperspb.String("hello")); err != nil {
+		t.Fatalf("send proto success fail. | err: %s", err)
+	}
+
+	envelope := &spb.Status{}
+
+	if err := ProtoCoder.Unmarshal(w.Body.Bytes(), envelope); err != nil {
+		t.Fatalf("unmarshal envelope fail. | err: %s", err)
+	}
+
+	value := &wrapperspb.StringValue{}
+
+	if envelope.Code != CodeSuccess || len(envelope.Details) != 1 || envelope.Details[0].UnmarshalTo(value) != nil || value.Value != "hello" {
+		t.Fatalf("unexpected envelope. | envelope: %v", envelope)
+	}
+
+	ctx, w = newTestContext(http.MethodGet, "", map[string]string{AcceptHeader: MimeTypeProtobuf})
+
+	if err := SendError(ctx, DefaultRegistry, errUserNotFound.WithData([]string{"a"})); err != nil {
+		t.Fatalf("send non proto data fail. | err: %s", err)
+	}
+
+	envelope = &spb.Status{}
+	info := &errdetails.ErrorInfo{}
+	list := &structpb.Value{}
+
+	if err := ProtoCoder.Unmarshal(w.Body.Bytes(), envelope); err != nil || w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected proto response. | code: %d | err: %v", w.Code, err)
+	}
+
+	// the status code is the grpc code, the business code travels in the error info
+	if codes.Code(envelope.Code) != codes.NotFound || len(envelope.Details) != 2 || envelope.Details[0].UnmarshalTo(info) != nil || info.Reason != "10001" {
+		t.Fatalf("unexpected envelope. | envelope: %v", envelope)
+	}
+
+	if envelope.Details[1].UnmarshalTo(list) != nil || list.GetListValue().GetValues()[0].GetStringValue() != "a" {
+		t.Fatalf("unexpected envelope. | envelope: %v", envelope)
+	}
+}
+
+func TestSendError(t *testing.T) {
+	ctx, w := newTestContext(http.MethodGet, "", nil)
+
+	if err := SendError(ctx, JsonCoder, errUserNotFound); err != nil {
+		t.Fatalf("send error fail. | err: %s", err)
+	}
+
+	if w.Code != http.StatusNotFound || w.Body.String() != `{"code":10001,"message":"user not found"}` {
+		t.Fatalf("unexpected response. | code: %d | body: %s", w.Code, w.Body.String())
+	}
+
+	validationError := &ValidationError{Fields: []*FieldError{{Field: "name", Tag: "required", Message: "name is required"}}}
+	ctx, w = newTestContext(http.MethodGet, "", map[string]string{AcceptHeader: MimeTypeProtobuf})
+
+	if err := SendError(ctx, DefaultRegistry, validationError); err != nil {
+		t.Fatalf("send validation error fail. | err: %s", err)
+	}
+
+	envelope := &spb.Status{}
+	badRequest := &errdetails.BadRequest{}
+
+	if err := ProtoCoder.Unmarshal(w.Body.Bytes(), envelope); err != nil || w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected proto response. | code: %d | err: %v", w.Code, err)
+	}
+
+	if codes.Code(envelope.Code) != codes.InvalidArgument || len(envelope.Details) != 2 || envelope.Details[1].UnmarshalTo(badRequest) != nil || badRequest.FieldViolations[0].Field != "name" {
+		t.Fatalf("unexpected envelope. | envelope: %v", envelope)
+	}
+}
+
+func TestErrorMapping(t *testing.T) {
+	if s, _ := status.FromError(errUserNotFound); s.Code() != codes.NotFound || s.Message() != "user not found" {
+		t.Fatalf("unexpected grpc status. | status: %v", s)
+	}
+
+	if s, _ := status.FromError(errUserNotFound.WithGrpcCode(codes.Aborted)); s.Code() != codes.Aborted {
+		t.Fatalf("unexpected grpc status. | status: %v", s)
+	}
+
+	if e := FromError(status.Error(codes.PermissionDenied, "denied")); e.Status != http.StatusForbidden {
+		t.Fatalf("unexpected http status. | error: %+v", e)
+	}
+
+	// internal errors are not sent as is
+	if e := FromError(errors.New("dial tcp 10.0.0.1:3306: connection refused")); e.Status != http.StatusInternalServerError || e.Message != MessageInternalError {
+		t.Fatalf("unexpected http status. | error: %+v", e)
+	}
+}
@@ -0,0 +1,51 @@
+package coder
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// DecodeGinRequest decodes the request body with codec, the body is cached on the context for later reads.
+func DecodeGinRequest(ctx *gin.Context, c ICodec, v interface{}) (err error) {
+	data, err := GetRequestBody(ctx)
+
+	if err != nil {
+		return
+	}
+
+	return c.Unmarshal(data, v)
+}
+
+// SendGinResponse writes v encoded with codec along with its Protocol-Encoding and Content-Type headers.
+func SendGinResponse(ctx *gin.Context, c ICodec, v interface{}) (err error) {
+	ctx.Header(EncodingHeader, c.Name())
+	ctx.Header(ContentTypeHeader, c.ContentType())
+
+	data, err := c.Marshal(v)
+
+	if err != nil {
+		return
+	}
+
+	return writeResponse(ctx, data)
+}
+
+// NewGinCoder adapts a transport agnostic codec to gin.
+func NewGinCoder(c ICodec) ICoder {
+	if coder, ok := c.(ICoder); ok {
+		return coder
+	}
+
+	return &ginCoder{ICodec: c}
+}
+
+type ginCoder struct {
+	ICodec
+}
+
+func (c *ginCoder) DecodeRequest(ctx *gin.Context, v interface{}) error {
+	return DecodeGinRequest(ctx, c.ICodec, v)
+}
+
+func (c *ginCoder) SendResponse(ctx *gin.Context, v interface{}) error {
+	return SendGinResponse(ctx, c.ICodec, v)
+}
@@ -0,0 +1,115 @@
+package coder
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+)
+
+// DecodeHttpRequest decodes a net/http request body with codec.
+func DecodeHttpRequest(r *http.Request, c ICodec, v interface{}) error {
+	data, err := ReadHttpBody(r)
+
+	if err != nil {
+		return err
+	}
+
+	return c.Unmarshal(data, v)
+}
+
+// WriteHttpResponse writes v encoded with codec to a net/http response writer.
+// r is only used for Accept-Encoding negotiation and may be nil.
+func WriteHttpResponse(w http.ResponseWriter, r *http.Request, c ICodec, status int, v interface{}) error {
+	data, err := c.Marshal(v)
+
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set(EncodingHeader, c.Name())
+	w.Header().Set(ContentTypeHeader, c.ContentType())
+
+	return writeBody(w, r, status, data)
+}
+
+// NewHttpRequest builds a client request whose body is v encoded with codec.
+func NewHttpRequest(ctx context.Context, method, url string, c ICodec, v interface{}) (*http.Request, error) {
+	var body io.Reader
+
+	if v != nil {
+		data, err := c.Marshal(v)
+
+		if err != nil {
+			return nil, err
+		}
+
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set(EncodingHeader, c.Name())
+	req.Header.Set(ContentTypeHeader, c.ContentType())
+	req.Header.Set(AcceptHeader, c.ContentType())
+	return req, nil
+}
+
+// DecodeHttpRequest decodes a net/http request with the coder negotiated from its headers.
+func (r *Registry) DecodeHttpRequest(req *http.Request, v interface{}) error {
+	return DecodeHttpRequest(req, r.GetDecoderByHeader(req.Header), v)
+}
+
+// WriteHttpResponse writes the response with the coder negotiated from the request headers.
+func (r *Registry) WriteHttpResponse(w http.ResponseWriter, req *http.Request, status int, v interface{}) error {
+	return WriteHttpResponse(w, req, r.GetEncoderByHeader(req.Header), status, v)
+}
+
+// DecodeHttpResponse decodes a client response with the coder picked from the response headers.
+func (r *Registry) DecodeHttpResponse(rsp *http.Response, v interface{}) error {
+	return DecodeHttpResponse(rsp, r.GetDecoderByHeader(rsp.Header), v)
+}
+
+// GetResponseDecoder picks the coder for the response to a request sent with c.
+// Protocol-Encoding wins, then a Content-Type that is not c's own and names a registered coder, then c itself.
+func (r *Registry) GetResponseDecoder(header http.Header, c ICodec) ICodec {
+	if decoder, ok := r.GetByEncoding(header.Get(EncodingHeader)); ok {
+		return decoder
+	}
+
+	// several coders share a Content-Type, e.g. json and protojson
+	if contentType := header.Get(ContentTypeHeader); contentType != "" && normalizeMimeType(contentType) != normalizeMimeType(c.ContentType()) {
+		if decoder, ok := r.GetByMimeType(contentType); ok {
+			return decoder
+		}
+	}
+
+	return c
+}
+
+// DecodeHttpResponse decodes a client response body, inflated by Content-Encoding, with codec.
+func DecodeHttpResponse(rsp *http.Response, c ICodec, v interface{}) error {
+	var body io.Reader = rsp.Body
+	decompressor, inflated, err := inflateBody(rsp.Header, rsp.Body)
+
+	if err != nil {
+		return err
+	}
+
+	if inflated {
+		defer decompressor.Close()
+		body = decompressor
+	}
+
+	data, err := io.ReadAll(body)
+
+	if err != nil {
+		return err
+	}
+
+	return c.Unmarshal(data, v)
+}
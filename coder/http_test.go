@@ -0,0 +1,101 @@
+package coder
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// upperCodec is a transport agnostic codec without gin methods
+type upperCodec struct{}
+
+func (c *upperCodec) Name() string        { return "upper" }
+func (c *upperCodec) ContentType() string { return "text/x-upper" }
+func (c *upperCodec) Marshal(v interface{}) ([]byte, error) {
+	return []byte(strings.ToUpper(*v.(*string))), nil
+}
+func (c *upperCodec) Unmarshal(data []byte, v interface{}) error {
+	*v.(*string) = string(data)
+	return nil
+}
+
+func TestHttpAdapter(t *testing.T) {
+	registry := NewRegistry(JsonCoder)
+	registry.Register(EncodingMsgpack, MsgpackCoder, MimeTypeMsgpack)
+	registry.RegisterCodec(&upperCodec{}, "text/x-upper")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := &binaryUser{}
+
+		if err := registry.DecodeHttpRequest(r, user); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		user.Age++
+		_ = registry.WriteHttpResponse(w, r, http.StatusCreated, user)
+	}))
+	defer server.Close()
+
+	for _, c := range []ICodec{JsonCoder, MsgpackCoder} {
+		req, err := NewHttpRequest(context.Background(), http.MethodPost, server.URL, c, &binaryUser{Name: "user", Age: 20})
+
+		if err != nil {
+			t.Fatalf("%s new request fail. | err: %s", c.Name(), err)
+		}
+
+		rsp, err := http.DefaultClient.Do(req)
+
+		if err != nil {
+			t.Fatalf("%s do request fail. | err: %s", c.Name(), err)
+		}
+
+		user := &binaryUser{}
+		err = registry.DecodeHttpResponse(rsp, user)
+		_ = rsp.Body.Close()
+
+		if err != nil || rsp.StatusCode != http.StatusCreated || rsp.Header.Get(EncodingHeader) != c.Name() || user.Age != 21 {
+			t.Fatalf("%s unexpected response. | code: %d | user: %+v | err: %v", c.Name(), rsp.StatusCode, user, err)
+		}
+	}
+
+	// the response decoder falls back to the codec of the request
+	registry.Register(EncodingJson, JsonCoder, MimeTypeJSON)
+	cases := []struct {
+		header map[string]string
+		codec  ICodec
+		expect ICodec
+	}{
+		{map[string]string{ContentTypeHeader: MimeTypeJSON}, ProtoJsonCoder, ProtoJsonCoder},
+		{map[string]string{}, ProtoCoder, ProtoCoder},
+		{map[string]string{ContentTypeHeader: "text/plain"}, ProtoCoder, ProtoCoder},
+		{map[string]string{ContentTypeHeader: MimeTypeMsgpack}, ProtoCoder, MsgpackCoder},
+		{map[string]string{EncodingHeader: EncodingJson, ContentTypeHeader: MimeTypeJSON}, ProtoJsonCoder, JsonCoder},
+	}
+
+	for i, c := range cases {
+		header := http.Header{}
+
+		for k, v := range c.header {
+			header.Set(k, v)
+		}
+
+		if decoder := registry.GetResponseDecoder(header, c.codec); decoder != c.expect {
+			t.Fatalf("case %d unexpected response decoder. | decoder: %s", i, decoder.Name())
+		}
+	}
+
+	// a plain codec registered on the registry also serves gin
+	ctx, w := newTestContext(http.MethodPost, "hello", map[string]string{ContentTypeHeader: "text/x-upper"})
+	value := ""
+
+	if err := registry.DecodeRequest(ctx, &value); err != nil || value != "hello" {
+		t.Fatalf("decode gin request fail. | value: %s | err: %v", value, err)
+	}
+
+	if err := registry.SendResponse(ctx, &value); err != nil || w.Body.String() != "HELLO" || w.Header().Get(EncodingHeader) != "upper" {
+		t.Fatalf("send gin response fail. | body: %s | err: %v", w.Body.String(), err)
+	}
+}
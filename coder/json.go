@@ -2,7 +2,6 @@ package coder
 
 import (
 	"bytes"
-	"encoding/json"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,61 +16,68 @@ type jsonCoder struct {
 	EscapeHTML            bool
 	UseNumber             bool
 	DisallowUnknownFields bool
+	// Engine is the json implementation, encoding/json when nil
+	Engine IJsonEngine
 }
 
-func (c *jsonCoder) Marshal(v interface{}) (data []byte, err error) {
-	if c.EscapeHTML {
-		return json.Marshal(v)
+func (c *jsonCoder) Name() string {
+	return EncodingJson
+}
+
+func (c *jsonCoder) ContentType() string {
+	return ContentTypeJSON
+}
+
+func (c *jsonCoder) getEngine() IJsonEngine {
+	if c.Engine == nil {
+		return StdJsonEngine
 	}
 
-	w := new(bytes.Buffer)
+	return c.Engine
+}
 
-	jsonEncoder := json.NewEncoder(w)
-	jsonEncoder.SetEscapeHTML(c.EscapeHTML)
+func (c *jsonCoder) Marshal(v interface{}) (data []byte, err error) {
+	w := getBuffer()
+	defer putBuffer(w)
 
-	if err = jsonEncoder.Encode(v); err != nil {
+	if err = c.getEngine().Marshal(w, v, c.EscapeHTML); err != nil {
 		return
 	}
 
-	data = make([]byte, w.Len())
-	copy(data, w.Bytes())
+	// encoders terminate the value with a newline
+	result := bytes.TrimSuffix(w.Bytes(), []byte{'\n'})
+	data = make([]byte, len(result))
+	copy(data, result)
 	return
 }
 
 func (c *jsonCoder) Unmarshal(data []byte, v interface{}) error {
-	decoder := json.NewDecoder(bytes.NewReader(data))
-
-	if c.UseNumber {
-		decoder.UseNumber()
-	}
-
-	if c.DisallowUnknownFields {
-		decoder.DisallowUnknownFields()
-	}
-
-	return decoder.Decode(v)
+	return c.getEngine().Unmarshal(data, v, c.UseNumber, c.DisallowUnknownFields)
 }
 
-func (c *jsonCoder) DecodeRequest(ctx *gin.Context, v interface{}) (err error) {
-	data, err := GetRequestBody(ctx)
-
-	if err != nil {
-		return
-	}
-
-	return c.Unmarshal(data, v)
+func (c *jsonCoder) DecodeRequest(ctx *gin.Context, v interface{}) error {
+	return DecodeGinRequest(ctx, c, v)
 }
 
 func (c *jsonCoder) SendResponse(ctx *gin.Context, v interface{}) (err error) {
-	ctx.Header(EncodingHeader, EncodingJson)
-	ctx.Header(ContentTypeHeader, ContentTypeJSON)
+	ctx.Header(EncodingHeader, c.Name())
+	ctx.Header(ContentTypeHeader, c.ContentType())
 
-	data, err := c.Marshal(v)
+	w := getBuffer()
+	defer putBuffer(w)
 
-	if err != nil {
+	if err = c.getEngine().Marshal(w, v, c.EscapeHTML); err != nil {
 		return
 	}
 
-	_, err = ctx.Writer.Write(data)
-	return
+	return writeResponse(ctx, bytes.TrimSuffix(w.Bytes(), []byte{'\n'}))
+}
+
+func NewJsonCoder(engine IJsonEngine, escapeHTML, useNumber, disallowUnknownFields bool) ICoder {
+	return &jsonCoder{
+		EscapeHTML:            escapeHTML,
+		UseNumber:             useNumber,
+		DisallowUnknownFields: disallowUnknownFields,
+		Engine:                engine,
+	}
 }
@@ -0,0 +1,129 @@
+package coder
+
+import (
+	"bytes"
+	"encoding/json"
+	gjson "github.com/goccy/go-json"
+	"sync"
+)
+
+const (
+	JsonEngineStd    = "std"
+	JsonEngineGoJson = "go-json"
+	JsonEngineSonic  = "sonic"
+)
+
+// IJsonEngine is the json implementation behind jsonCoder.
+// Marshal appends v to buffer, Unmarshal decodes data into v with the given decoder switches.
+type IJsonEngine interface {
+	Marshal(buffer *bytes.Buffer, v interface{}, escapeHTML bool) error
+	Unmarshal(data []byte, v interface{}, useNumber, disallowUnknownFields bool) error
+}
+
+var (
+	StdJsonEngine    IJsonEngine = &stdJsonEngine{}
+	GoJsonEngine     IJsonEngine = &goJsonEngine{}
+	SonicJsonEngine  IJsonEngine
+	jsonEnginesMutex sync.RWMutex
+	jsonEngines      = map[string]IJsonEngine{
+		JsonEngineStd:    StdJsonEngine,
+		JsonEngineGoJson: GoJsonEngine,
+	}
+)
+
+// GetJsonEngine looks an engine up by name. sonic is only present when built with the sonic tag.
+func GetJsonEngine(name string) (engine IJsonEngine, ok bool) {
+	jsonEnginesMutex.RLock()
+	defer jsonEnginesMutex.RUnlock()
+
+	engine, ok = jsonEngines[name]
+	return
+}
+
+func RegisterJsonEngine(name string, engine IJsonEngine) {
+	jsonEnginesMutex.Lock()
+	defer jsonEnginesMutex.Unlock()
+
+	jsonEngines[name] = engine
+}
+
+// buffers larger than this are dropped instead of going back to the pool
+const maxPooledBufferSize = 64 * 1024
+
+var bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+var readerPool = sync.Pool{New: func() any { return new(bytes.Reader) }}
+
+func getBuffer() *bytes.Buffer {
+	buffer := bufferPool.Get().(*bytes.Buffer)
+	buffer.Reset()
+	return buffer
+}
+
+func putBuffer(buffer *bytes.Buffer) {
+	if buffer.Cap() > maxPooledBufferSize {
+		return
+	}
+
+	bufferPool.Put(buffer)
+}
+
+type stdJsonEngine struct{}
+
+func (e *stdJsonEngine) Marshal(buffer *bytes.Buffer, v interface{}, escapeHTML bool) error {
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(escapeHTML)
+	return encoder.Encode(v)
+}
+
+func (e *stdJsonEngine) Unmarshal(data []byte, v interface{}, useNumber, disallowUnknownFields bool) error {
+	if !useNumber && !disallowUnknownFields {
+		return json.Unmarshal(data, v)
+	}
+
+	reader := readerPool.Get().(*bytes.Reader)
+	reader.Reset(data)
+	defer readerPool.Put(reader)
+
+	decoder := json.NewDecoder(reader)
+
+	if useNumber {
+		decoder.UseNumber()
+	}
+
+	if disallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+
+	return decoder.Decode(v)
+}
+
+type goJsonEngine struct{}
+
+func (e *goJsonEngine) Marshal(buffer *bytes.Buffer, v interface{}, escapeHTML bool) error {
+	encoder := gjson.NewEncoder(buffer)
+	encoder.SetEscapeHTML(escapeHTML)
+	return encoder.Encode(v)
+}
+
+func (e *goJsonEngine) Unmarshal(data []byte, v interface{}, useNumber, disallowUnknownFields bool) error {
+	if !useNumber && !disallowUnknownFields {
+		return gjson.Unmarshal(data, v)
+	}
+
+	reader := readerPool.Get().(*bytes.Reader)
+	reader.Reset(data)
+	defer readerPool.Put(reader)
+
+	decoder := gjson.NewDecoder(reader)
+
+	if useNumber {
+		decoder.UseNumber()
+	}
+
+	if disallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+
+	return decoder.Decode(v)
+}
@@ -0,0 +1,60 @@
+//go:build sonic && avx && (linux || windows || darwin) && amd64
+
+package coder
+
+import (
+	"bytes"
+	"github.com/bytedance/sonic"
+)
+
+func init() {
+	SonicJsonEngine = newSonicJsonEngine()
+	RegisterJsonEngine(JsonEngineSonic, SonicJsonEngine)
+}
+
+type sonicJsonEngine struct {
+	// frozen configs indexed by escapeHTML, useNumber, disallowUnknownFields
+	apis [2][2][2]sonic.API
+}
+
+func (e *sonicJsonEngine) Marshal(buffer *bytes.Buffer, v interface{}, escapeHTML bool) error {
+	data, err := e.apis[sonicIndex(escapeHTML)][0][0].Marshal(v)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = buffer.Write(data)
+	return err
+}
+
+func (e *sonicJsonEngine) Unmarshal(data []byte, v interface{}, useNumber, disallowUnknownFields bool) error {
+	return e.apis[1][sonicIndex(useNumber)][sonicIndex(disallowUnknownFields)].Unmarshal(data, v)
+}
+
+func sonicIndex(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+func newSonicJsonEngine() *sonicJsonEngine {
+	engine := &sonicJsonEngine{}
+
+	for i, escapeHTML := range []bool{false, true} {
+		for j, useNumber := range []bool{false, true} {
+			for k, disallowUnknownFields := range []bool{false, true} {
+				engine.apis[i][j][k] = sonic.Config{
+					EscapeHTML:            escapeHTML,
+					UseNumber:             useNumber,
+					DisallowUnknownFields: disallowUnknownFields,
+					SortMapKeys:           true,
+				}.Froze()
+			}
+		}
+	}
+
+	return engine
+}
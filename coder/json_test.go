@@ -0,0 +1,101 @@
+package coder
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type jsonUser struct {
+	Name    string            `json:"name"`
+	Age     int               `json:"age"`
+	Profile string            `json:"profile"`
+	Tags    []string          `json:"tags"`
+	Extra   map[string]string `json:"extra"`
+}
+
+var benchUser = &jsonUser{
+	Name:    "user",
+	Age:     20,
+	Profile: strings.Repeat("<b>profile</b>", 16),
+	Tags:    []string{"a", "b", "c", "d"},
+	Extra:   map[string]string{"k1": "v1", "k2": "v2"},
+}
+
+func availableJsonEngines() map[string]IJsonEngine {
+	engines := map[string]IJsonEngine{}
+
+	for _, name := range []string{JsonEngineStd, JsonEngineGoJson, JsonEngineSonic} {
+		if engine, ok := GetJsonEngine(name); ok {
+			engines[name] = engine
+		}
+	}
+
+	return engines
+}
+
+func TestJsonEngine(t *testing.T) {
+	for name, engine := range availableJsonEngines() {
+		escape := NewJsonCoder(engine, true, true, false)
+		data, err := escape.Marshal(map[string]string{"html": "<a>"})
+
+		if err != nil || string(data) != `{"html":"\u003ca\u003e"}` {
+			t.Fatalf("%s escape html fail. | data: %s | err: %v", name, data, err)
+		}
+
+		noEscape := NewJsonCoder(engine, false, false, true)
+		data, err = noEscape.Marshal(map[string]string{"html": "<a>"})
+
+		if err != nil || string(data) != `{"html":"<a>"}` {
+			t.Fatalf("%s no escape html fail. | data: %s | err: %v", name, data, err)
+		}
+
+		result := map[string]interface{}{}
+
+		if err = escape.Unmarshal([]byte(`{"id":12345678901234567890}`), &result); err != nil {
+			t.Fatalf("%s unmarshal fail. | err: %s", name, err)
+		}
+
+		if n, ok := result["id"].(json.Number); !ok || n.String() != "12345678901234567890" {
+			t.Fatalf("%s use number fail. | result: %v", name, result)
+		}
+
+		if err = noEscape.Unmarshal([]byte(`{"name":"a","unknown":1}`), &jsonUser{}); err == nil {
+			t.Fatalf("%s disallow unknown fields fail", name)
+		}
+	}
+}
+
+func BenchmarkJsonCoder_Marshal(b *testing.B) {
+	for name, engine := range availableJsonEngines() {
+		c := NewJsonCoder(engine, true, true, false)
+
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := c.Marshal(benchUser); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkJsonCoder_Unmarshal(b *testing.B) {
+	data, _ := JsonCoder.Marshal(benchUser)
+
+	for name, engine := range availableJsonEngines() {
+		c := NewJsonCoder(engine, true, true, false)
+
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				if err := c.Unmarshal(data, &jsonUser{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
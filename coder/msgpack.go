@@ -0,0 +1,57 @@
+package coder
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
+	"reflect"
+)
+
+const (
+	EncodingMsgpack    = "msgpack"
+	ContentTypeMsgpack = "application/msgpack"
+)
+
+const (
+	MimeTypeMsgpack      = "application/msgpack"
+	MimeTypeMsgpackAlias = "application/x-msgpack"
+)
+
+var MsgpackCoder = newMsgpackCoder()
+
+type msgpackCoder struct {
+	handle *codec.MsgpackHandle
+}
+
+func (c *msgpackCoder) Name() string {
+	return EncodingMsgpack
+}
+
+func (c *msgpackCoder) ContentType() string {
+	return ContentTypeMsgpack
+}
+
+func (c *msgpackCoder) Unmarshal(data []byte, v interface{}) error {
+	return codec.NewDecoderBytes(data, c.handle).Decode(v)
+}
+
+func (c *msgpackCoder) Marshal(v interface{}) (data []byte, err error) {
+	err = codec.NewEncoderBytes(&data, c.handle).Encode(v)
+	return
+}
+
+func (c *msgpackCoder) DecodeRequest(ctx *gin.Context, v interface{}) error {
+	return DecodeGinRequest(ctx, c, v)
+}
+
+func (c *msgpackCoder) SendResponse(ctx *gin.Context, v interface{}) error {
+	return SendGinResponse(ctx, c, v)
+}
+
+func newMsgpackCoder() *msgpackCoder {
+	// new spec, strings and binary are kept apart and decode to string / []byte
+	handle := &codec.MsgpackHandle{WriteExt: true}
+	handle.RawToString = true
+	handle.MapType = reflect.TypeOf(map[string]interface{}(nil))
+
+	return &msgpackCoder{handle: handle}
+}
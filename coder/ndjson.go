@@ -0,0 +1,69 @@
+package coder
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"io"
+)
+
+const (
+	EncodingNdjson    = "ndjson"
+	ContentTypeNdjson = "application/x-ndjson"
+)
+
+const (
+	MimeTypeNdjson = "application/x-ndjson"
+)
+
+// NdjsonCoder streams newline delimited json records, one json value per line.
+var NdjsonCoder = &ndjsonCoder{EscapeHTML: true, UseNumber: true, DisallowUnknownFields: false, FlushSize: 1}
+
+type ndjsonCoder struct {
+	EscapeHTML            bool
+	UseNumber             bool
+	DisallowUnknownFields bool
+	FlushSize             int
+}
+
+type ndjsonReader struct {
+	decoder *json.Decoder
+}
+
+func (r *ndjsonReader) Next(v interface{}) error {
+	return r.decoder.Decode(v)
+}
+
+func (c *ndjsonCoder) NewReader(r io.Reader) IStreamReader {
+	decoder := json.NewDecoder(r)
+
+	if c.UseNumber {
+		decoder.UseNumber()
+	}
+
+	if c.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+
+	return &ndjsonReader{decoder: decoder}
+}
+
+func (c *ndjsonCoder) NewWriter(w io.Writer) IStreamWriter {
+	return newStreamWriter(w, c.FlushSize, func(w io.Writer) func(v interface{}) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(c.EscapeHTML)
+
+		// Encode terminates every value with a newline
+		return encoder.Encode
+	})
+}
+
+func (c *ndjsonCoder) DecodeStream(ctx *gin.Context, newValue func() interface{}, do func(v interface{}) error) error {
+	return decodeStream(ctx, c, newValue, do)
+}
+
+func (c *ndjsonCoder) SendStream(ctx *gin.Context, do func(w IStreamWriter) error) error {
+	ctx.Header(EncodingHeader, EncodingNdjson)
+	ctx.Header(ContentTypeHeader, ContentTypeNdjson)
+
+	return sendStream(ctx, c, do)
+}
@@ -15,6 +15,14 @@ var ProtoCoder = &protoCoder{}
 
 type protoCoder struct{}
 
+func (c *protoCoder) Name() string {
+	return EncodingProtobuf
+}
+
+func (c *protoCoder) ContentType() string {
+	return ContentTypeProtobuf
+}
+
 func (c *protoCoder) Unmarshal(data []byte, v interface{}) error {
 	pb, ok := v.(proto.Message)
 
@@ -35,26 +43,10 @@ func (c *protoCoder) Marshal(v interface{}) ([]byte, error) {
 	return proto.Marshal(pb)
 }
 
-func (c *protoCoder) DecodeRequest(ctx *gin.Context, v interface{}) (err error) {
-	data, err := GetRequestBody(ctx)
-
-	if err != nil {
-		return
-	}
-
-	return c.Unmarshal(data, v)
+func (c *protoCoder) DecodeRequest(ctx *gin.Context, v interface{}) error {
+	return DecodeGinRequest(ctx, c, v)
 }
 
-func (c *protoCoder) SendResponse(ctx *gin.Context, v interface{}) (err error) {
-	ctx.Header(EncodingHeader, EncodingProtobuf)
-	ctx.Header(ContentTypeHeader, ContentTypeProtobuf)
-
-	data, err := c.Marshal(v)
-
-	if err != nil {
-		return
-	}
-
-	_, err = ctx.Writer.Write(data)
-	return
+func (c *protoCoder) SendResponse(ctx *gin.Context, v interface{}) error {
+	return SendGinResponse(ctx, c, v)
 }
@@ -0,0 +1,67 @@
+package coder
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	EncodingProtoJson = "protojson"
+)
+
+// ProtoJsonCoder encodes protobuf messages with the canonical proto3 JSON mapping, the same JSON grpc gateways produce.
+var ProtoJsonCoder = &protoJsonCoder{EmitUnpopulated: false, UseProtoNames: false, DiscardUnknown: true}
+
+type protoJsonCoder struct {
+	EmitUnpopulated bool
+	UseProtoNames   bool
+	DiscardUnknown  bool
+}
+
+func (c *protoJsonCoder) Name() string {
+	return EncodingProtoJson
+}
+
+func (c *protoJsonCoder) ContentType() string {
+	return ContentTypeJSON
+}
+
+func (c *protoJsonCoder) Unmarshal(data []byte, v interface{}) error {
+	pb, ok := v.(proto.Message)
+
+	if !ok {
+		return errors.New("invalid protobuf message")
+	}
+
+	options := protojson.UnmarshalOptions{DiscardUnknown: c.DiscardUnknown}
+	return options.Unmarshal(data, proto.MessageV2(pb))
+}
+
+func (c *protoJsonCoder) Marshal(v interface{}) ([]byte, error) {
+	pb, ok := v.(proto.Message)
+
+	if !ok {
+		return nil, errors.New("invalid protobuf message")
+	}
+
+	options := protojson.MarshalOptions{EmitUnpopulated: c.EmitUnpopulated, UseProtoNames: c.UseProtoNames}
+	return options.Marshal(proto.MessageV2(pb))
+}
+
+func (c *protoJsonCoder) DecodeRequest(ctx *gin.Context, v interface{}) error {
+	return DecodeGinRequest(ctx, c, v)
+}
+
+func (c *protoJsonCoder) SendResponse(ctx *gin.Context, v interface{}) error {
+	return SendGinResponse(ctx, c, v)
+}
+
+func NewProtoJsonCoder(emitUnpopulated, useProtoNames, discardUnknown bool) ICoder {
+	return &protoJsonCoder{
+		EmitUnpopulated: emitUnpopulated,
+		UseProtoNames:   useProtoNames,
+		DiscardUnknown:  discardUnknown,
+	}
+}
@@ -0,0 +1,54 @@
+package coder
+
+import (
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"testing"
+	"time"
+)
+
+func TestProtoJsonCoder_Marshal(t *testing.T) {
+	cases := []struct {
+		message interface{}
+		expect  string
+	}{
+		{durationpb.New(90 * time.Second), `"90s"`},
+		{wrapperspb.Int64(1 << 60), `"1152921504606846976"`},
+		{structpb.NewNullValue(), `null`},
+	}
+
+	for i, c := range cases {
+		data, err := ProtoJsonCoder.Marshal(c.message)
+
+		if err != nil {
+			t.Fatalf("case %d marshal fail. | err: %s", i, err)
+		}
+
+		if string(data) != c.expect {
+			t.Fatalf("case %d unexpected json. | data: %s", i, data)
+		}
+	}
+
+	if _, err := ProtoJsonCoder.Marshal(map[string]string{}); err == nil {
+		t.Fatalf("marshal non protobuf value should fail")
+	}
+}
+
+func TestProtoJsonCoder_Unmarshal(t *testing.T) {
+	s := &structpb.Struct{}
+
+	if err := ProtoJsonCoder.Unmarshal([]byte(`{"name":"test","age":20}`), s); err != nil {
+		t.Fatalf("unmarshal fail. | err: %s", err)
+	}
+
+	if s.Fields["name"].GetStringValue() != "test" || s.Fields["age"].GetNumberValue() != 20 {
+		t.Fatalf("unexpected message. | message: %v", s)
+	}
+
+	d := &durationpb.Duration{}
+
+	if err := ProtoJsonCoder.Unmarshal([]byte(`"1.5s"`), d); err != nil || d.AsDuration() != 1500*time.Millisecond {
+		t.Fatalf("unmarshal duration fail. | err: %v | duration: %v", err, d)
+	}
+}
@@ -0,0 +1,76 @@
+package coder
+
+import (
+	"bufio"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"io"
+)
+
+const (
+	EncodingProtobufStream    = "protobuf-stream"
+	ContentTypeProtobufStream = "application/x-protobuf-stream"
+)
+
+const (
+	MimeTypeProtobufStream = "application/x-protobuf-stream"
+)
+
+// ProtoStreamCoder streams length delimited protobuf messages, every message is prefixed with its varint size.
+// MaxRecordSize limits a single message, 0 means the protodelim default of 4MB.
+var ProtoStreamCoder = &protoStreamCoder{MaxRecordSize: 0, FlushSize: 1}
+
+type protoStreamCoder struct {
+	MaxRecordSize int64
+	FlushSize     int
+}
+
+type protoStreamReader struct {
+	reader  *bufio.Reader
+	options protodelim.UnmarshalOptions
+}
+
+func (r *protoStreamReader) Next(v interface{}) error {
+	pb, ok := v.(proto.Message)
+
+	if !ok {
+		return errors.New("invalid protobuf message")
+	}
+
+	return r.options.UnmarshalFrom(r.reader, proto.MessageV2(pb))
+}
+
+func (c *protoStreamCoder) NewReader(r io.Reader) IStreamReader {
+	return &protoStreamReader{
+		reader:  bufio.NewReader(r),
+		options: protodelim.UnmarshalOptions{MaxSize: c.MaxRecordSize},
+	}
+}
+
+func (c *protoStreamCoder) NewWriter(w io.Writer) IStreamWriter {
+	return newStreamWriter(w, c.FlushSize, func(w io.Writer) func(v interface{}) error {
+		return func(v interface{}) error {
+			pb, ok := v.(proto.Message)
+
+			if !ok {
+				return errors.New("invalid protobuf message")
+			}
+
+			_, err := protodelim.MarshalTo(w, proto.MessageV2(pb))
+			return err
+		}
+	})
+}
+
+func (c *protoStreamCoder) DecodeStream(ctx *gin.Context, newValue func() interface{}, do func(v interface{}) error) error {
+	return decodeStream(ctx, c, newValue, do)
+}
+
+func (c *protoStreamCoder) SendStream(ctx *gin.Context, do func(w IStreamWriter) error) error {
+	ctx.Header(EncodingHeader, EncodingProtobufStream)
+	ctx.Header(ContentTypeHeader, ContentTypeProtobufStream)
+
+	return sendStream(ctx, c, do)
+}
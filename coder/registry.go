@@ -0,0 +1,239 @@
+package coder
+
+import (
+	"github.com/gin-gonic/gin"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	AcceptHeader = "Accept"
+)
+
+const (
+	MimeTypeJSON          = "application/json"
+	MimeTypeProtobuf      = "application/x-protobuf"
+	MimeTypeProtobufAlias = "application/protobuf"
+)
+
+var DefaultRegistry = NewRegistry(JsonCoder)
+
+func init() {
+	DefaultRegistry.Register(EncodingJson, JsonCoder, MimeTypeJSON)
+	DefaultRegistry.Register(EncodingProtobuf, ProtoCoder, MimeTypeProtobuf, MimeTypeProtobufAlias)
+	DefaultRegistry.Register(EncodingProtoJson, ProtoJsonCoder)
+	DefaultRegistry.Register(EncodingMsgpack, MsgpackCoder, MimeTypeMsgpack, MimeTypeMsgpackAlias)
+	DefaultRegistry.Register(EncodingCbor, CborCoder, MimeTypeCbor)
+}
+
+// Registry maps Protocol-Encoding values and MIME types to coders and picks one per request.
+// The decoder is chosen by Protocol-Encoding, then Content-Type. The encoder is chosen by Accept,
+// then Protocol-Encoding, then Content-Type. The default coder is used when nothing matches.
+type Registry struct {
+	locker       sync.RWMutex
+	encodings    map[string]ICoder
+	mimeTypes    map[string]ICoder
+	mimeOrder    []string
+	defaultCoder ICoder
+}
+
+// Register binds a coder to a Protocol-Encoding value and any number of MIME types.
+func (r *Registry) Register(encoding string, c ICoder, mimeTypes ...string) {
+	r.locker.Lock()
+	defer r.locker.Unlock()
+
+	if encoding != "" {
+		r.encodings[strings.ToLower(encoding)] = c
+	}
+
+	for _, t := range mimeTypes {
+		t = normalizeMimeType(t)
+
+		if _, ok := r.mimeTypes[t]; !ok {
+			r.mimeOrder = append(r.mimeOrder, t)
+		}
+
+		r.mimeTypes[t] = c
+	}
+}
+
+// RegisterCodec binds a transport agnostic codec under its own name, it is adapted to gin for the server side.
+func (r *Registry) RegisterCodec(c ICodec, mimeTypes ...string) {
+	r.Register(c.Name(), NewGinCoder(c), mimeTypes...)
+}
+
+func (r *Registry) SetDefault(c ICoder) {
+	r.locker.Lock()
+	defer r.locker.Unlock()
+
+	r.defaultCoder = c
+}
+
+func (r *Registry) GetDefault() ICoder {
+	r.locker.RLock()
+	defer r.locker.RUnlock()
+
+	return r.defaultCoder
+}
+
+func (r *Registry) GetByEncoding(encoding string) (c ICoder, ok bool) {
+	r.locker.RLock()
+	defer r.locker.RUnlock()
+
+	c, ok = r.encodings[strings.ToLower(strings.TrimSpace(encoding))]
+	return
+}
+
+func (r *Registry) GetByMimeType(mimeType string) (c ICoder, ok bool) {
+	r.locker.RLock()
+	defer r.locker.RUnlock()
+
+	c, ok = r.mimeTypes[normalizeMimeType(mimeType)]
+	return
+}
+
+// GetDecoder returns the coder used to read the request body.
+func (r *Registry) GetDecoder(ctx *gin.Context) ICoder {
+	return r.GetDecoderByHeader(ctx.Request.Header)
+}
+
+// GetEncoder returns the coder used to write the response body.
+func (r *Registry) GetEncoder(ctx *gin.Context) ICoder {
+	return r.GetEncoderByHeader(ctx.Request.Header)
+}
+
+// GetDecoderByHeader picks the coder for a body described by header, this works for requests and responses alike.
+func (r *Registry) GetDecoderByHeader(header http.Header) ICoder {
+	if c, ok := r.GetByEncoding(header.Get(EncodingHeader)); ok {
+		return c
+	}
+
+	if c, ok := r.GetByMimeType(header.Get(ContentTypeHeader)); ok {
+		return c
+	}
+
+	return r.GetDefault()
+}
+
+// GetEncoderByHeader picks the coder for the response to a request with header.
+func (r *Registry) GetEncoderByHeader(header http.Header) ICoder {
+	if c, ok := r.matchAccept(header.Get(AcceptHeader)); ok {
+		return c
+	}
+
+	return r.GetDecoderByHeader(header)
+}
+
+func (r *Registry) Name() string {
+	return r.GetDefault().Name()
+}
+
+func (r *Registry) ContentType() string {
+	return r.GetDefault().ContentType()
+}
+
+func (r *Registry) Unmarshal(data []byte, v interface{}) error {
+	return r.GetDefault().Unmarshal(data, v)
+}
+
+func (r *Registry) Marshal(v interface{}) ([]byte, error) {
+	return r.GetDefault().Marshal(v)
+}
+
+func (r *Registry) DecodeRequest(ctx *gin.Context, v interface{}) error {
+	return r.GetDecoder(ctx).DecodeRequest(ctx, v)
+}
+
+func (r *Registry) SendResponse(ctx *gin.Context, v interface{}) error {
+	return r.GetEncoder(ctx).SendResponse(ctx, v)
+}
+
+type acceptItem struct {
+	mimeType string
+	quality  float64
+}
+
+func (r *Registry) matchAccept(accept string) (c ICoder, ok bool) {
+	if accept == "" {
+		return
+	}
+
+	items := parseAccept(accept)
+
+	r.locker.RLock()
+	defer r.locker.RUnlock()
+
+	for _, item := range items {
+		if item.quality <= 0 {
+			continue
+		}
+
+		if c, ok = r.mimeTypes[item.mimeType]; ok {
+			return
+		}
+
+		// any type is acceptable, let the caller fall back to the request encoding
+		if item.mimeType == "*/*" {
+			return nil, false
+		}
+
+		if prefix, found := strings.CutSuffix(item.mimeType, "/*"); found {
+			for _, t := range r.mimeOrder {
+				if strings.HasPrefix(t, prefix+"/") {
+					return r.mimeTypes[t], true
+				}
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func parseAccept(accept string) []*acceptItem {
+	parts := strings.Split(accept, ",")
+	items := make([]*acceptItem, 0, len(parts))
+
+	for _, part := range parts {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+
+		if err != nil {
+			continue
+		}
+
+		item := &acceptItem{mimeType: mediaType, quality: 1}
+
+		if q, ok := params["q"]; ok {
+			if quality, err := strconv.ParseFloat(q, 64); err == nil {
+				item.quality = quality
+			}
+		}
+
+		items = append(items, item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].quality > items[j].quality
+	})
+
+	return items
+}
+
+func normalizeMimeType(mimeType string) string {
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+		return mediaType
+	}
+
+	return strings.ToLower(strings.TrimSpace(mimeType))
+}
+
+func NewRegistry(defaultCoder ICoder) *Registry {
+	return &Registry{
+		encodings:    make(map[string]ICoder),
+		mimeTypes:    make(map[string]ICoder),
+		defaultCoder: defaultCoder,
+	}
+}
@@ -0,0 +1,92 @@
+package coder
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestContext(method, body string, header map[string]string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(method, "/", bytes.NewBufferString(body))
+
+	for k, v := range header {
+		ctx.Request.Header.Set(k, v)
+	}
+
+	return ctx, w
+}
+
+func TestRegistry_GetDecoder(t *testing.T) {
+	cases := []struct {
+		header map[string]string
+		expect ICoder
+	}{
+		{nil, JsonCoder},
+		{map[string]string{EncodingHeader: EncodingProtobuf}, ProtoCoder},
+		{map[string]string{ContentTypeHeader: ContentTypeProtobuf}, ProtoCoder},
+		{map[string]string{ContentTypeHeader: ContentTypeJSON}, JsonCoder},
+		{map[string]string{EncodingHeader: EncodingJson, ContentTypeHeader: ContentTypeProtobuf}, JsonCoder},
+	}
+
+	for i, c := range cases {
+		ctx, _ := newTestContext(http.MethodPost, "", c.header)
+
+		if coder := DefaultRegistry.GetDecoder(ctx); coder != c.expect {
+			t.Fatalf("case %d get decoder fail. | coder: %T", i, coder)
+		}
+	}
+}
+
+func TestRegistry_GetEncoder(t *testing.T) {
+	cases := []struct {
+		header map[string]string
+		expect ICoder
+	}{
+		{nil, JsonCoder},
+		{map[string]string{AcceptHeader: "application/x-protobuf"}, ProtoCoder},
+		{map[string]string{AcceptHeader: "application/json;q=0.5, application/protobuf"}, ProtoCoder},
+		{map[string]string{AcceptHeader: "text/html, */*", ContentTypeHeader: ContentTypeProtobuf}, ProtoCoder},
+		{map[string]string{AcceptHeader: "application/x-protobuf;q=0, application/json"}, JsonCoder},
+	}
+
+	for i, c := range cases {
+		ctx, _ := newTestContext(http.MethodGet, "", c.header)
+
+		if coder := DefaultRegistry.GetEncoder(ctx); coder != c.expect {
+			t.Fatalf("case %d get encoder fail. | coder: %T", i, coder)
+		}
+	}
+}
+
+func TestRegistry_Negotiate(t *testing.T) {
+	data, err := ProtoCoder.Marshal(wrapperspb.String("hello"))
+
+	if err != nil {
+		t.Fatalf("marshal fail. | err: %s", err)
+	}
+
+	ctx, w := newTestContext(http.MethodPost, string(data), map[string]string{
+		ContentTypeHeader: ContentTypeProtobuf,
+		AcceptHeader:      MimeTypeJSON,
+	})
+
+	req := &wrapperspb.StringValue{}
+
+	if err = DefaultRegistry.DecodeRequest(ctx, req); err != nil {
+		t.Fatalf("decode request fail. | err: %s", err)
+	}
+
+	if err = DefaultRegistry.SendResponse(ctx, map[string]string{"value": req.Value}); err != nil {
+		t.Fatalf("send response fail. | err: %s", err)
+	}
+
+	if w.Header().Get(EncodingHeader) != EncodingJson || w.Body.String() != `{"value":"hello"}` {
+		t.Fatalf("unexpected response. | header: %v | body: %s", w.Header(), w.Body.String())
+	}
+}
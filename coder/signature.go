@@ -0,0 +1,308 @@
+package coder
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Signature"
+	TimestampHeader = "X-Timestamp"
+	NonceHeader     = "X-Nonce"
+)
+
+const (
+	SignatureEncodingHex       = "hex"
+	SignatureEncodingBase64    = "base64"
+	SignatureEncodingBase64Url = "base64url"
+)
+
+var (
+	ErrSignatureMissing = errors.New("signature missing")
+	ErrSignatureInvalid = errors.New("signature invalid")
+	ErrTimestampInvalid = errors.New("signature timestamp invalid")
+	ErrTimestampExpired = errors.New("signature timestamp out of window")
+	ErrNonceReplayed    = errors.New("signature nonce replayed")
+)
+
+// ISignVerifier checks a signature over the signed message.
+type ISignVerifier interface {
+	Verify(message, signature []byte) error
+}
+
+// INonceStore remembers nonces for ttl. Use returns false if the nonce was already used.
+type INonceStore interface {
+	Use(nonce string, ttl time.Duration) (bool, error)
+}
+
+type hmacVerifier struct {
+	key []byte
+}
+
+func (v *hmacVerifier) Verify(message, signature []byte) error {
+	mac := hmac.New(sha256.New, v.key)
+	mac.Write(message)
+
+	if !hmac.Equal(mac.Sum(nil), signature) {
+		return ErrSignatureInvalid
+	}
+
+	return nil
+}
+
+// NewHmacVerifier verifies HMAC-SHA256 signatures.
+func NewHmacVerifier(key []byte) ISignVerifier {
+	return &hmacVerifier{key: key}
+}
+
+type rsaVerifier struct {
+	publicKey *rsa.PublicKey
+}
+
+func (v *rsaVerifier) Verify(message, signature []byte) error {
+	digest := sha256.Sum256(message)
+
+	if rsa.VerifyPKCS1v15(v.publicKey, crypto.SHA256, digest[:], signature) != nil {
+		return ErrSignatureInvalid
+	}
+
+	return nil
+}
+
+// NewRsaVerifier verifies RSA PKCS#1 v1.5 SHA-256 signatures with a PEM encoded public key.
+func NewRsaVerifier(publicKeyString string) (ISignVerifier, error) {
+	block, _ := pem.Decode([]byte(publicKeyString))
+
+	if block == nil {
+		return nil, errors.New("invalid rsa public key pem")
+	}
+
+	if publicKey, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return &rsaVerifier{publicKey: publicKey}, nil
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, ok := key.(*rsa.PublicKey)
+
+	if !ok {
+		return nil, errors.New("not a rsa public key")
+	}
+
+	return &rsaVerifier{publicKey: publicKey}, nil
+}
+
+// SignatureConfig verifies signed request bodies.
+// The signed message is "{timestamp}.{body}", or "{timestamp}.{nonce}.{body}" when the nonce header is sent.
+// The body is the raw body as sent, a compressed upload is verified before inflation.
+// The timestamp is in unix seconds, the signature is in Encoding, optionally prefixed by "sha256=".
+type SignatureConfig struct {
+	Verifier        ISignVerifier
+	SignatureHeader string
+	TimestampHeader string
+	NonceHeader     string
+	// Encoding of the signature header, hex when empty, base64 padding is optional
+	Encoding string
+	// Skew is the accepted distance between the timestamp and now, 5 minutes when 0
+	Skew time.Duration
+	// NonceStore rejects replays within the skew window, the signature is the key when no nonce is sent
+	NonceStore INonceStore
+}
+
+func (c *SignatureConfig) getSkew() time.Duration {
+	if c.Skew <= 0 {
+		return 5 * time.Minute
+	}
+
+	return c.Skew
+}
+
+// VerifyRequest checks the signature over the request body cached by GetRequestBody.
+func (c *SignatureConfig) VerifyRequest(ctx *gin.Context) error {
+	signature := ctx.GetHeader(c.SignatureHeader)
+	timestamp := ctx.GetHeader(c.TimestampHeader)
+
+	if signature == "" || timestamp == "" {
+		return ErrSignatureMissing
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+
+	if err != nil {
+		return ErrTimestampInvalid
+	}
+
+	if diff := time.Since(time.Unix(seconds, 0)); diff > c.getSkew() || diff < -c.getSkew() {
+		return ErrTimestampExpired
+	}
+
+	signatureBytes, err := decodeSignature(signature, c.Encoding)
+
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+
+	body, err := GetRawRequestBody(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	nonce := ""
+
+	if c.NonceHeader != "" {
+		nonce = ctx.GetHeader(c.NonceHeader)
+	}
+
+	message := make([]byte, 0, len(timestamp)+len(nonce)+len(body)+2)
+	message = append(message, timestamp...)
+	message = append(message, '.')
+
+	if nonce != "" {
+		message = append(message, nonce...)
+		message = append(message, '.')
+	}
+
+	message = append(message, body...)
+
+	if err = c.Verifier.Verify(message, signatureBytes); err != nil {
+		return err
+	}
+
+	if c.NonceStore == nil {
+		return nil
+	}
+
+	if nonce == "" {
+		nonce = signature
+	}
+
+	// the timestamp check already rejects anything older than the window
+	fresh, err := c.NonceStore.Use(nonce, 2*c.getSkew())
+
+	if err != nil {
+		return err
+	}
+
+	if !fresh {
+		return ErrNonceReplayed
+	}
+
+	return nil
+}
+
+// Middleware aborts unsigned or badly signed requests with 401 before any handler decodes the body.
+func (c *SignatureConfig) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := c.VerifyRequest(ctx); err != nil {
+			appError := NewError(http.StatusUnauthorized, http.StatusUnauthorized, err.Error())
+
+			if errors.Is(err, ErrBodyTooLarge) {
+				appError = FromError(err)
+			}
+
+			_ = SendError(ctx, DefaultRegistry, appError)
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// NewSignatureConfig returns a config with the default X-Signature, X-Timestamp and X-Nonce headers and hex signatures.
+func NewSignatureConfig(verifier ISignVerifier, nonceStore INonceStore) *SignatureConfig {
+	return &SignatureConfig{
+		Verifier:        verifier,
+		SignatureHeader: SignatureHeader,
+		TimestampHeader: TimestampHeader,
+		NonceHeader:     NonceHeader,
+		Encoding:        SignatureEncodingHex,
+		NonceStore:      nonceStore,
+	}
+}
+
+// WithSignature wraps a coder so DecodeRequest verifies the body signature before unmarshal.
+func WithSignature(c ICoder, conf *SignatureConfig) ICoder {
+	return &signatureCoder{ICoder: c, conf: conf}
+}
+
+type signatureCoder struct {
+	ICoder
+	conf *SignatureConfig
+}
+
+func (c *signatureCoder) DecodeRequest(ctx *gin.Context, v interface{}) error {
+	if err := c.conf.VerifyRequest(ctx); err != nil {
+		return err
+	}
+
+	return c.ICoder.DecodeRequest(ctx, v)
+}
+
+func decodeSignature(signature, encoding string) ([]byte, error) {
+	signature = strings.TrimPrefix(strings.TrimSpace(signature), "sha256=")
+
+	switch encoding {
+	case "", SignatureEncodingHex:
+		return hex.DecodeString(signature)
+	case SignatureEncodingBase64:
+		return base64.RawStdEncoding.DecodeString(strings.TrimRight(signature, "="))
+	case SignatureEncodingBase64Url:
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(signature, "="))
+	}
+
+	return nil, errors.New("unsupported signature encoding")
+}
+
+// MemoryNonceStore keeps nonces in process memory, expired entries are purged while new ones are added.
+type MemoryNonceStore struct {
+	locker sync.Mutex
+	nonces map[string]time.Time
+	purged time.Time
+}
+
+func (s *MemoryNonceStore) Use(nonce string, ttl time.Duration) (bool, error) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(s.purged) > time.Minute {
+		for k, expire := range s.nonces {
+			if now.After(expire) {
+				delete(s.nonces, k)
+			}
+		}
+
+		s.purged = now
+	}
+
+	if expire, ok := s.nonces[nonce]; ok && now.Before(expire) {
+		return false, nil
+	}
+
+	s.nonces[nonce] = now.Add(ttl)
+	return true, nil
+}
+
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: make(map[string]time.Time)}
+}
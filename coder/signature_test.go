@@ -0,0 +1,171 @@
+package coder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func hmacSign(key []byte, message string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestSignatureConfig_Hmac(t *testing.T) {
+	key := []byte("webhook secret")
+	conf := NewSignatureConfig(NewHmacVerifier(key), NewMemoryNonceStore())
+	body := `{"name":"user","age":20}`
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	header := map[string]string{
+		TimestampHeader: timestamp,
+		NonceHeader:     "nonce-1",
+		SignatureHeader: "sha256=" + hmacSign(key, timestamp+".nonce-1."+body),
+	}
+
+	ctx, _ := newTestContext(http.MethodPost, body, header)
+	user := &binaryUser{}
+
+	if err := WithSignature(JsonCoder, conf).DecodeRequest(ctx, user); err != nil || user.Name != "user" {
+		t.Fatalf("verify signature fail. | err: %v", err)
+	}
+
+	ctx, _ = newTestContext(http.MethodPost, body, header)
+
+	if err := conf.VerifyRequest(ctx); err != ErrNonceReplayed {
+		t.Fatalf("expect replay. | err: %v", err)
+	}
+
+	// the signature is checked before the body is unmarshalled
+	header[NonceHeader] = "nonce-2"
+	ctx, _ = newTestContext(http.MethodPost, "not json", header)
+
+	if err := WithSignature(JsonCoder, conf).DecodeRequest(ctx, user); err != ErrSignatureInvalid {
+		t.Fatalf("expect invalid signature. | err: %v", err)
+	}
+
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	header = map[string]string{TimestampHeader: old, SignatureHeader: hmacSign(key, old+"."+body)}
+	ctx, w := newTestContext(http.MethodPost, body, header)
+	conf.Middleware()(ctx)
+
+	if !ctx.IsAborted() || w.Code != http.StatusUnauthorized {
+		t.Fatalf("expect middleware abort. | code: %d", w.Code)
+	}
+}
+
+func TestSignatureConfig_Compressed(t *testing.T) {
+	key := []byte("webhook secret")
+	conf := NewSignatureConfig(NewHmacVerifier(key), nil)
+	body := `{"name":"user","age":20}`
+	buffer := new(bytes.Buffer)
+	writer := gzip.NewWriter(buffer)
+	_, _ = writer.Write([]byte(body))
+	_ = writer.Close()
+
+	// the partner signs the bytes it sends
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	header := map[string]string{
+		ContentEncodingHeader: CompressGzip,
+		TimestampHeader:       timestamp,
+		SignatureHeader:       hmacSign(key, timestamp+"."+buffer.String()),
+	}
+
+	ctx, _ := newTestContext(http.MethodPost, buffer.String(), header)
+	user := &binaryUser{}
+
+	if err := WithSignature(JsonCoder, conf).DecodeRequest(ctx, user); err != nil || user.Name != "user" {
+		t.Fatalf("verify compressed body fail. | err: %v", err)
+	}
+
+	// the raw body is kept when the decoded one was cached first
+	ctx, _ = newTestContext(http.MethodPost, buffer.String(), header)
+
+	if data, _ := GetRequestBody(ctx); string(data) != body {
+		t.Fatalf("unexpected cached body. | body: %s", data)
+	}
+
+	if err := conf.VerifyRequest(ctx); err != nil {
+		t.Fatalf("verify compressed body after decode fail. | err: %s", err)
+	}
+
+	// a body sent as is is not copied
+	ctx, _ = newTestContext(http.MethodPost, body, nil)
+	_, _ = GetRequestBody(ctx)
+
+	if _, ok := ctx.Get(RawBodyBytesKey); ok {
+		t.Fatalf("unexpected raw body copy")
+	}
+
+	if data, _ := GetRawRequestBody(ctx); string(data) != body {
+		t.Fatalf("unexpected raw body. | body: %s", data)
+	}
+}
+
+func TestDecodeSignature(t *testing.T) {
+	// only hex characters, but meant as base64
+	signature := "abcd1234"
+	expect, _ := base64.StdEncoding.DecodeString(signature)
+
+	if data, err := decodeSignature(signature, SignatureEncodingBase64); err != nil || !bytes.Equal(data, expect) {
+		t.Fatalf("unexpected base64 signature. | data: %x | err: %v", data, err)
+	}
+
+	if data, err := decodeSignature("sha256="+signature, SignatureEncodingHex); err != nil || hex.EncodeToString(data) != signature {
+		t.Fatalf("unexpected hex signature. | data: %x | err: %v", data, err)
+	}
+
+	if _, err := decodeSignature(signature, "base32"); err == nil {
+		t.Fatalf("expect unsupported encoding")
+	}
+}
+
+func TestSignatureConfig_Rsa(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatalf("generate key fail. | err: %s", err)
+	}
+
+	publicKey, _ := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	verifier, err := NewRsaVerifier(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})))
+
+	if err != nil {
+		t.Fatalf("new rsa verifier fail. | err: %s", err)
+	}
+
+	body := `{"name":"user"}`
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	digest := sha256.Sum256([]byte(timestamp + "." + body))
+	signature, _ := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, digest[:])
+
+	conf := NewSignatureConfig(verifier, NewMemoryNonceStore())
+	conf.Encoding = SignatureEncodingBase64
+	header := map[string]string{TimestampHeader: timestamp, SignatureHeader: base64.StdEncoding.EncodeToString(signature)}
+
+	ctx, _ := newTestContext(http.MethodPost, body, header)
+
+	if err = conf.VerifyRequest(ctx); err != nil {
+		t.Fatalf("verify rsa signature fail. | err: %s", err)
+	}
+
+	// without a nonce header the signature itself guards against replays
+	ctx, _ = newTestContext(http.MethodPost, body, header)
+
+	if err = conf.VerifyRequest(ctx); err != ErrNonceReplayed {
+		t.Fatalf("expect replay. | err: %v", err)
+	}
+}
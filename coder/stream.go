@@ -0,0 +1,109 @@
+package coder
+
+import (
+	"bufio"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+)
+
+// IStreamReader decodes one record per call to Next and returns io.EOF after the last record.
+type IStreamReader interface {
+	Next(v interface{}) error
+}
+
+// IStreamWriter encodes one record per call to Write. Flush pushes buffered records to the client.
+type IStreamWriter interface {
+	Write(v interface{}) error
+	Flush() error
+}
+
+type IStreamCoder interface {
+	NewReader(r io.Reader) IStreamReader
+	NewWriter(w io.Writer) IStreamWriter
+	DecodeStream(ctx *gin.Context, newValue func() interface{}, do func(v interface{}) error) error
+	SendStream(ctx *gin.Context, do func(w IStreamWriter) error) error
+}
+
+func decodeStream(ctx *gin.Context, c IStreamCoder, newValue func() interface{}, do func(v interface{}) error) error {
+	body, err := GetRequestReader(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	reader := c.NewReader(body)
+
+	for {
+		v := newValue()
+
+		if err = reader.Next(v); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return err
+		}
+
+		if err = do(v); err != nil {
+			return err
+		}
+	}
+}
+
+func sendStream(ctx *gin.Context, c IStreamCoder, do func(w IStreamWriter) error) error {
+	ctx.Status(http.StatusOK)
+	writer := c.NewWriter(ctx.Writer)
+
+	if err := do(writer); err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+// streamWriter buffers encoded records and flushes every flushSize records.
+type streamWriter struct {
+	buffer    *bufio.Writer
+	writer    io.Writer
+	encode    func(v interface{}) error
+	flushSize int
+	count     int
+}
+
+func (w *streamWriter) Write(v interface{}) error {
+	if err := w.encode(v); err != nil {
+		return err
+	}
+
+	w.count++
+
+	if w.flushSize > 0 && w.count%w.flushSize == 0 {
+		return w.Flush()
+	}
+
+	return nil
+}
+
+func (w *streamWriter) Flush() error {
+	if err := w.buffer.Flush(); err != nil {
+		return err
+	}
+
+	if flusher, ok := w.writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	return nil
+}
+
+func newStreamWriter(w io.Writer, flushSize int, newEncoder func(w io.Writer) func(v interface{}) error) *streamWriter {
+	writer := &streamWriter{
+		buffer:    bufio.NewWriter(w),
+		writer:    w,
+		flushSize: flushSize,
+	}
+
+	writer.encode = newEncoder(writer.buffer)
+	return writer
+}
@@ -0,0 +1,113 @@
+package coder
+
+import (
+	"bytes"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNdjsonCoder(t *testing.T) {
+	body := "{\"name\":\"a\",\"age\":1}\n{\"name\":\"b\",\"age\":2}\n\n{\"name\":\"c\",\"age\":3}\n"
+	ctx, w := newTestContext(http.MethodPost, body, map[string]string{ContentTypeHeader: ContentTypeNdjson})
+	users := make([]*binaryUser, 0)
+
+	err := NdjsonCoder.DecodeStream(ctx, func() interface{} { return &binaryUser{} }, func(v interface{}) error {
+		users = append(users, v.(*binaryUser))
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("decode stream fail. | err: %s", err)
+	}
+
+	if len(users) != 3 || users[2].Name != "c" || users[2].Age != 3 {
+		t.Fatalf("unexpected records. | count: %d", len(users))
+	}
+
+	err = NdjsonCoder.SendStream(ctx, func(sw IStreamWriter) error {
+		for _, u := range users {
+			if e := sw.Write(map[string]interface{}{"name": u.Name}); e != nil {
+				return e
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("send stream fail. | err: %s", err)
+	}
+
+	if w.Body.String() != "{\"name\":\"a\"}\n{\"name\":\"b\"}\n{\"name\":\"c\"}\n" || !w.Flushed {
+		t.Fatalf("unexpected response. | body: %s", w.Body.String())
+	}
+}
+
+func TestProtoStreamCoder(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	writer := ProtoStreamCoder.NewWriter(buffer)
+
+	for _, s := range []string{"a", "bb", "ccc"} {
+		if err := writer.Write(wrapperspb.String(s)); err != nil {
+			t.Fatalf("write record fail. | err: %s", err)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("flush fail. | err: %s", err)
+	}
+
+	ctx, _ := newTestContext(http.MethodPost, buffer.String(), nil)
+	values := make([]string, 0)
+
+	err := ProtoStreamCoder.DecodeStream(ctx, func() interface{} { return &wrapperspb.StringValue{} }, func(v interface{}) error {
+		values = append(values, v.(*wrapperspb.StringValue).Value)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("decode stream fail. | err: %s", err)
+	}
+
+	if strings.Join(values, ",") != "a,bb,ccc" {
+		t.Fatalf("unexpected records. | values: %v", values)
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	MaxBodySize = 8
+	defer func() { MaxBodySize = 0 }()
+
+	ctx, _ := newTestContext(http.MethodPost, `{"name":"too large"}`, nil)
+
+	if _, err := GetRequestBody(ctx); err != ErrBodyTooLarge {
+		t.Fatalf("expect body too large. | err: %v", err)
+	}
+
+	// unknown content length, the limit is hit while reading
+	ctx, _ = newTestContext(http.MethodPost, "", nil)
+	ctx.Request.Body = newReadCloser(strings.Repeat("{}\n", 10))
+	ctx.Request.ContentLength = -1
+
+	err := NdjsonCoder.DecodeStream(ctx, func() interface{} { return &map[string]interface{}{} }, func(v interface{}) error {
+		return nil
+	})
+
+	if err != ErrBodyTooLarge {
+		t.Fatalf("expect stream body too large. | err: %v", err)
+	}
+}
+
+type readCloser struct {
+	*strings.Reader
+}
+
+func (r *readCloser) Close() error {
+	return nil
+}
+
+func newReadCloser(s string) *readCloser {
+	return &readCloser{strings.NewReader(s)}
+}
@@ -0,0 +1,183 @@
+package coder
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Validator checks the `validate` struct tags after decode. Field names in errors follow the json tag.
+// Custom rules can be registered on it directly.
+var Validator = newValidator()
+
+// IValidator is implemented by request types that validate themselves after the struct tags pass.
+type IValidator interface {
+	Validate() error
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError carries every failed field so the http layer can answer 400 with per-field messages.
+type ValidationError struct {
+	Fields []*FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+
+	for _, f := range e.Fields {
+		messages = append(messages, f.Message)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// Messages returns the error message of every field keyed by field name.
+func (e *ValidationError) Messages() map[string]string {
+	result := make(map[string]string, len(e.Fields))
+
+	for _, f := range e.Fields {
+		result[f.Field] = f.Message
+	}
+
+	return result
+}
+
+func IsValidationError(err error) (*ValidationError, bool) {
+	var validationError *ValidationError
+	ok := errors.As(err, &validationError)
+	return validationError, ok
+}
+
+// Validate runs the struct tags and then the Validate method of v.
+func Validate(v interface{}) error {
+	if isStruct(v) {
+		if err := Validator.Struct(v); err != nil {
+			var fieldErrors validator.ValidationErrors
+
+			if !errors.As(err, &fieldErrors) {
+				return err
+			}
+
+			result := &ValidationError{Fields: make([]*FieldError, 0, len(fieldErrors))}
+
+			for _, fe := range fieldErrors {
+				result.Fields = append(result.Fields, newFieldError(fe))
+			}
+
+			return result
+		}
+	}
+
+	if iv, ok := v.(IValidator); ok {
+		if err := iv.Validate(); err != nil {
+			if _, ok = IsValidationError(err); ok {
+				return err
+			}
+
+			return &ValidationError{Fields: []*FieldError{{Message: err.Error()}}}
+		}
+	}
+
+	return nil
+}
+
+// WithValidation wraps a coder so DecodeRequest validates the target after decoding.
+func WithValidation(c ICoder) ICoder {
+	return &validateCoder{ICoder: c}
+}
+
+type validateCoder struct {
+	ICoder
+}
+
+func (c *validateCoder) DecodeRequest(ctx *gin.Context, v interface{}) error {
+	if err := c.ICoder.DecodeRequest(ctx, v); err != nil {
+		return err
+	}
+
+	return Validate(v)
+}
+
+func newFieldError(fe validator.FieldError) *FieldError {
+	field := fe.Namespace()
+
+	// drop the top level struct name
+	if i := strings.Index(field, "."); i >= 0 {
+		field = field[i+1:]
+	}
+
+	return &FieldError{
+		Field:   field,
+		Tag:     fe.Tag(),
+		Message: fieldMessage(field, fe),
+	}
+}
+
+func fieldMessage(field string, fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "min", "gte":
+		return fmt.Sprintf("%s must be at least %s", field, fe.Param())
+	case "max", "lte":
+		return fmt.Sprintf("%s must be at most %s", field, fe.Param())
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", field, fe.Param())
+	case "lt":
+		return fmt.Sprintf("%s must be less than %s", field, fe.Param())
+	case "len":
+		return fmt.Sprintf("%s must have length %s", field, fe.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of [%s]", field, fe.Param())
+	case "email":
+		return fmt.Sprintf("%s must be a valid email", field)
+	}
+
+	if fe.Param() != "" {
+		return fmt.Sprintf("%s failed on %s=%s", field, fe.Tag(), fe.Param())
+	}
+
+	return fmt.Sprintf("%s failed on %s", field, fe.Tag())
+}
+
+func isStruct(v interface{}) bool {
+	t := reflect.TypeOf(v)
+
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t != nil && t.Kind() == reflect.Struct
+}
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+
+		if name == "-" {
+			return ""
+		}
+
+		if name == "" {
+			return field.Name
+		}
+
+		return name
+	})
+
+	return v
+}
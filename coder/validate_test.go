@@ -0,0 +1,64 @@
+package coder
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+type validateAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type validateUser struct {
+	Name    string           `json:"name" validate:"required"`
+	Age     int              `json:"age" validate:"gte=18"`
+	Role    string           `json:"role" validate:"oneof=admin user"`
+	Address *validateAddress `json:"address" validate:"required"`
+}
+
+func (u *validateUser) Validate() error {
+	if u.Role == "admin" && u.Age < 30 {
+		return errors.New("admin must be at least 30")
+	}
+
+	return nil
+}
+
+func TestWithValidation(t *testing.T) {
+	c := WithValidation(JsonCoder)
+
+	ctx, _ := newTestContext(http.MethodPost, `{"age":10,"role":"guest","address":{}}`, nil)
+	err := c.DecodeRequest(ctx, &validateUser{})
+	validationError, ok := IsValidationError(err)
+
+	if !ok {
+		t.Fatalf("expect validation error. | err: %v", err)
+	}
+
+	messages := validationError.Messages()
+
+	if len(messages) != 4 || messages["name"] != "name is required" || messages["address.city"] == "" {
+		t.Fatalf("unexpected field errors. | messages: %v", messages)
+	}
+
+	ctx, _ = newTestContext(http.MethodPost, `{"name":"a","age":20,"role":"admin","address":{"city":"x"}}`, nil)
+	err = c.DecodeRequest(ctx, &validateUser{})
+
+	if validationError, ok = IsValidationError(err); !ok || validationError.Error() != "admin must be at least 30" {
+		t.Fatalf("expect Validate method error. | err: %v", err)
+	}
+
+	ctx, _ = newTestContext(http.MethodPost, `{"name":"a","age":40,"role":"admin","address":{"city":"x"}}`, nil)
+
+	if err = c.DecodeRequest(ctx, &validateUser{}); err != nil {
+		t.Fatalf("decode request fail. | err: %s", err)
+	}
+
+	// non struct targets only go through decode
+	ctx, _ = newTestContext(http.MethodPost, `{"name":""}`, nil)
+
+	if err = c.DecodeRequest(ctx, &map[string]interface{}{}); err != nil {
+		t.Fatalf("decode map fail. | err: %s", err)
+	}
+}
@@ -0,0 +1,253 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"github.com/dylanpeng/golib/jwt"
+	gjwt "github.com/golang-jwt/jwt/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+	"sync"
+	"time"
+)
+
+const AuthorizationMetadata = "authorization"
+
+type claimsContextKey struct{}
+
+// AuthConfig validates the bearer token in the authorization metadata of incoming calls.
+// SkipMethods are full method names like /pkg.Service/Method, /pkg.Service/* skips a whole service.
+type AuthConfig struct {
+	Client      *jwt.JwtClient
+	NewClaims   func() gjwt.Claims
+	SkipMethods []string
+}
+
+func (c *AuthConfig) isSkipped(method string) bool {
+	for _, m := range c.SkipMethods {
+		if m == method {
+			return true
+		}
+
+		if prefix, ok := strings.CutSuffix(m, "*"); ok && strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authenticate returns ctx carrying the claims of the call token, or an Unauthenticated status.
+func (c *AuthConfig) Authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(AuthorizationMetadata)
+
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, jwt.ErrTokenMissing.Error())
+	}
+
+	value := strings.TrimSpace(values[0])
+
+	if len(value) > len(jwt.SchemeBearer) && strings.EqualFold(value[:len(jwt.SchemeBearer)], jwt.SchemeBearer) && value[len(jwt.SchemeBearer)] == ' ' {
+		value = strings.TrimSpace(value[len(jwt.SchemeBearer):])
+	}
+
+	claims := c.NewClaims()
+	var err error
+
+	if tokenClaims, ok := claims.(jwt.ITokenClaims); ok {
+		_, err = c.Client.ParseAccessToken(value, tokenClaims)
+	} else {
+		_, err = c.Client.ParseToken(value, claims)
+	}
+
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return context.WithValue(ctx, claimsContextKey{}, claims), nil
+}
+
+func (c *AuthConfig) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if c.isSkipped(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx, err := c.Authenticate(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func (c *AuthConfig) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if c.isSkipped(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		ctx, err := c.Authenticate(ss.Context())
+
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// ServerOptions returns both interceptors for NewServer or Server.AddOpt.
+func (c *AuthConfig) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(c.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(c.StreamServerInterceptor()),
+	}
+}
+
+func NewAuthConfig(client *jwt.JwtClient, newClaims func() gjwt.Claims, skipMethods ...string) *AuthConfig {
+	return &AuthConfig{Client: client, NewClaims: newClaims, SkipMethods: skipMethods}
+}
+
+type authServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// ClaimsFromContext returns the claims stored by the server interceptors as T.
+func ClaimsFromContext[T any](ctx context.Context) (result T, ok bool) {
+	result, ok = ctx.Value(claimsContextKey{}).(T)
+	return
+}
+
+// ITokenSource returns the token to send with outgoing calls.
+type ITokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenFetcher obtains a new token and its expiry.
+type TokenFetcher func(ctx context.Context) (token string, expiresAt time.Time, err error)
+
+// RefreshTokenSource caches a fetched token and fetches a new one RefreshBefore its expiry.
+type RefreshTokenSource struct {
+	locker        sync.Mutex
+	fetch         TokenFetcher
+	token         string
+	expiresAt     time.Time
+	RefreshBefore time.Duration
+}
+
+func (s *RefreshTokenSource) Token(ctx context.Context) (string, error) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	if s.token != "" && time.Until(s.expiresAt) > s.RefreshBefore {
+		return s.token, nil
+	}
+
+	token, expiresAt, err := s.fetch(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	s.token, s.expiresAt = token, expiresAt
+	return token, nil
+}
+
+func NewRefreshTokenSource(fetch TokenFetcher, refreshBefore time.Duration) *RefreshTokenSource {
+	return &RefreshTokenSource{fetch: fetch, RefreshBefore: refreshBefore}
+}
+
+// NewJwtTokenSource issues tokens locally for service to service calls. The first call issues a pair,
+// later calls exchange the refresh token and fall back to a new pair when the exchange fails.
+func NewJwtTokenSource(client *jwt.JwtClient, newClaims func() jwt.ITokenClaims, refreshBefore time.Duration) *RefreshTokenSource {
+	var pair *jwt.TokenPair
+
+	return NewRefreshTokenSource(func(ctx context.Context) (string, time.Time, error) {
+		var err error
+
+		if pair != nil && time.Now().Before(pair.RefreshExpiresAt) {
+			if next, e := client.Refresh(pair.RefreshToken, newClaims()); e == nil {
+				pair = next
+				return pair.AccessToken, pair.AccessExpiresAt, nil
+			}
+		}
+
+		if pair, err = client.IssueTokenPair(newClaims()); err != nil {
+			return "", time.Time{}, err
+		}
+
+		return pair.AccessToken, pair.AccessExpiresAt, nil
+	}, refreshBefore)
+}
+
+type tokenCredentials struct {
+	source     ITokenSource
+	requireTLS bool
+}
+
+func (c *tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := c.source.Token(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{AuthorizationMetadata: jwt.SchemeBearer + " " + token}, nil
+}
+
+func (c *tokenCredentials) RequireTransportSecurity() bool {
+	return c.requireTLS
+}
+
+// NewTokenCredentials returns PerRPCCredentials for grpc.WithPerRPCCredentials.
+// requireTLS should only be false for plaintext connections inside a trusted network.
+func NewTokenCredentials(source ITokenSource, requireTLS bool) credentials.PerRPCCredentials {
+	return &tokenCredentials{source: source, requireTLS: requireTLS}
+}
+
+func attachToken(ctx context.Context, source ITokenSource) (context.Context, error) {
+	token, err := source.Token(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("get token fail: %w", err)
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, AuthorizationMetadata, jwt.SchemeBearer+" "+token), nil
+}
+
+func UnaryClientInterceptor(source ITokenSource) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, err := attachToken(ctx, source)
+
+		if err != nil {
+			return err
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func StreamClientInterceptor(source ITokenSource) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, err := attachToken(ctx, source)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
@@ -0,0 +1,92 @@
+package grpc
+
+import (
+	"context"
+	"github.com/dylanpeng/golib/jwt"
+	gjwt "github.com/golang-jwt/jwt/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthInterceptors(t *testing.T) {
+	client, err := jwt.NewJwtClient(&jwt.Config{PrivateKey: "secret", SignType: jwt.SignTypeHS, SignMethod: jwt.SignMethodHS256, ExpireTime: 60})
+
+	if err != nil {
+		t.Fatalf("new jwt client fail. | err: %s", err)
+	}
+
+	newClaims := func() jwt.ITokenClaims {
+		claims := &jwt.TokenClaims{}
+		claims.Subject = "service-a"
+		return claims
+	}
+
+	source := NewJwtTokenSource(client, newClaims, 10*time.Second)
+	auth := NewAuthConfig(client, func() gjwt.Claims { return &jwt.TokenClaims{} }, "/health.Health/*")
+
+	var outgoing metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		outgoing, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+
+	if err = UnaryClientInterceptor(source)(context.Background(), "/svc.Svc/Get", nil, nil, nil, invoker); err != nil {
+		t.Fatalf("client interceptor fail. | err: %s", err)
+	}
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		claims, ok := ClaimsFromContext[*jwt.TokenClaims](ctx)
+
+		if !ok {
+			return "", nil
+		}
+
+		return claims.Subject, nil
+	}
+
+	token := strings.TrimPrefix(outgoing.Get(AuthorizationMetadata)[0], jwt.SchemeBearer+" ")
+	cases := []struct {
+		method string
+		md     metadata.MD
+		code   codes.Code
+		expect interface{}
+	}{
+		{"/svc.Svc/Get", outgoing, codes.OK, "service-a"},
+		{"/svc.Svc/Get", metadata.Pairs(AuthorizationMetadata, "Bearer invalid"), codes.Unauthenticated, nil},
+		{"/svc.Svc/Get", metadata.Pairs(AuthorizationMetadata, jwt.SchemeBearer+token), codes.Unauthenticated, nil},
+		{"/svc.Svc/Get", nil, codes.Unauthenticated, nil},
+		{"/health.Health/Check", nil, codes.OK, ""},
+	}
+
+	for i, c := range cases {
+		ctx := metadata.NewIncomingContext(context.Background(), c.md)
+		rsp, err := auth.UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: c.method}, handler)
+
+		if status.Code(err) != c.code || rsp != c.expect {
+			t.Fatalf("case %d unexpected result. | rsp: %v | err: %v", i, rsp, err)
+		}
+	}
+
+	first, _ := source.Token(context.Background())
+	source.RefreshBefore = time.Minute
+
+	if second, err := source.Token(context.Background()); err != nil || second == first {
+		t.Fatalf("token not refreshed. | err: %v", err)
+	}
+
+	// the options can be added to a server built without options
+	server := NewServer(&Config{Host: "127.0.0.1"}, nil, nil)
+
+	for _, opt := range auth.ServerOptions() {
+		server.AddOpt(opt)
+	}
+
+	if len(server.opts) != 2 {
+		t.Fatalf("unexpected server options. | count: %d", len(server.opts))
+	}
+}
@@ -56,7 +56,7 @@ func (s *Server) AddOpt(opt grpc.ServerOption) {
 	}
 
 	if s.opts == nil {
-		s.opts = make([]grpc.ServerOption, 8)
+		s.opts = make([]grpc.ServerOption, 0, 8)
 	}
 
 	s.opts = append(s.opts, opt)
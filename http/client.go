@@ -3,8 +3,10 @@ package http
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"crypto/tls"
 	"fmt"
+	"github.com/dylanpeng/golib/coder"
 	"io"
 	"net/http"
 	"net/http/cookiejar"
@@ -70,6 +72,42 @@ func (c *Client) Do(method, url string, header map[string]string, body []byte) (
 	return
 }
 
+// DoCodec sends req encoded with codec and decodes the response into rsp with codec,
+// unless the response headers name another registered coder.
+func (c *Client) DoCodec(method, url string, header map[string]string, codec coder.ICodec, req, rsp interface{}) (rspCode int, err error) {
+	request, err := coder.NewHttpRequest(context.Background(), method, url, codec, req)
+
+	if err != nil {
+		return
+	}
+
+	for k, v := range header {
+		request.Header.Set(k, v)
+	}
+
+	response, err := c.client.Do(request)
+
+	if err != nil {
+		return
+	}
+
+	defer response.Body.Close()
+
+	rspCode = response.StatusCode
+
+	if rspCode != http.StatusOK {
+		err = fmt.Errorf("error http code %d", rspCode)
+		return
+	}
+
+	if rsp == nil {
+		return
+	}
+
+	err = coder.DecodeHttpResponse(response, coder.DefaultRegistry.GetResponseDecoder(response.Header, codec), rsp)
+	return
+}
+
 func NewClient(timeout time.Duration) *Client {
 	cookie, _ := cookiejar.New(nil)
 	return &Client{client: &http.Client{Jar: cookie, Timeout: timeout}}
@@ -2,13 +2,17 @@ package jwt
 
 import (
 	"errors"
+	"github.com/dylanpeng/golib/coder"
 	gjwt "github.com/golang-jwt/jwt/v4"
+	"time"
 )
 
 const (
 	SignTypeHS = "HS"
 	SignTypeRS = "RS"
 	SignTypeES = "ES"
+	SignTypePS = "PS"
+	SignTypeEd = "EdDSA"
 )
 
 type Config struct {
@@ -18,42 +22,151 @@ type Config struct {
 	SignMethod        string `toml:"sign_method" json:"sign_method" yaml:"sign_method"`
 	ExpireTime        int    `toml:"expire_time" json:"expire_time" yaml:"expire_time"`
 	RefreshExpireTime int    `toml:"refresh_expire_time" json:"refresh_expire_time" yaml:"refresh_expire_time"`
+	// Kid is stamped in the token header. With Keys it selects the current signing key
+	Kid  string       `toml:"kid" json:"kid" yaml:"kid"`
+	Keys []*KeyConfig `toml:"keys" json:"keys" yaml:"keys"`
+	// JwksUrl is the key set verified against with the JWKS sign type
+	JwksUrl string `toml:"jwks_url" json:"jwks_url" yaml:"jwks_url"`
+	// Validation adds issuer, audience, leeway and required claim checks to ParseToken
+	Validation *ValidationConfig `toml:"validation" json:"validation" yaml:"validation"`
+	// Encryption nests signed tokens in a JWE
+	Encryption *EncryptionConfig `toml:"encryption" json:"encryption" yaml:"encryption"`
 }
 
 type JwtClient struct {
-	conf        *Config
-	keyProvider IKeyProvider
+	conf            *Config
+	keyProvider     IKeyProvider
+	refreshStore    coder.INonceStore
+	revocationStore IRevocationStore
+	encrypter       *Encrypter
 }
 
 func (c *JwtClient) GenerateToken(claims gjwt.Claims) (tokenString string, err error) {
-	token := gjwt.NewWithClaims(c.keyProvider.GetSigningMethod(), claims)
-	tokenString, err = token.SignedString(c.keyProvider.GetPrivateKey())
+	kid, method, key := "", c.keyProvider.GetSigningMethod(), c.keyProvider.GetPrivateKey()
 
-	return
+	if p, ok := c.keyProvider.(IKidKeyProvider); ok {
+		kid, method, key = p.GetSigningKey()
+	}
+
+	if method == nil {
+		return "", errors.New("no signing key")
+	}
+
+	token := gjwt.NewWithClaims(method, claims)
+
+	if kid != "" {
+		token.Header[HeaderKid] = kid
+	}
+
+	if tokenString, err = token.SignedString(key); err != nil {
+		return
+	}
+
+	return c.encrypt(tokenString)
 }
 
 func (c *JwtClient) ParseToken(tokenString string, claims gjwt.Claims) (token *gjwt.Token, err error) {
-	token, err = gjwt.ParseWithClaims(tokenString, claims, c.keyProvider.GetPublicKey)
+	if tokenString, err = c.decrypt(tokenString); err != nil {
+		return
+	}
+
+	token, err = gjwt.NewParser(c.parserOptions()...).ParseWithClaims(tokenString, claims, c.keyFunc)
+
+	if err != nil {
+		return
+	}
+
+	if c.revocationStore == nil && c.getValidation() == nil {
+		return
+	}
+
+	mapClaims := gjwt.MapClaims{}
+
+	if _, _, err = new(gjwt.Parser).ParseUnverified(tokenString, mapClaims); err != nil {
+		return nil, err
+	}
+
+	if err = c.validateClaims(mapClaims); err != nil {
+		return nil, err
+	}
+
+	if c.getValidation() != nil {
+		if err = validateCustomClaims(claims); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = c.checkRevoked(mapClaims); err != nil {
+		return nil, err
+	}
 
 	return
 }
 
-func NewJwtClient(conf *Config) (client *JwtClient, err error) {
-	client = &JwtClient{
-		conf: conf,
-	}
+// GetKeyProvider returns the provider, a *MultiKeyProvider when keys or kid are configured.
+func (c *JwtClient) GetKeyProvider() IKeyProvider {
+	return c.keyProvider
+}
 
-	keyProvider, err := GetKeyProvider(client.conf)
+func NewJwtClient(conf *Config) (client *JwtClient, err error) {
+	keyProvider, err := GetKeyProvider(conf)
 
 	if err != nil {
 		return nil, err
 	}
 
-	client.keyProvider = keyProvider
+	return NewJwtClientWithProvider(conf, keyProvider)
+}
+
+// NewJwtClientWithProvider builds a client on a provider created by the caller, e.g. a MultiKeyProvider.
+// The keys of conf are ignored, the other settings such as Encryption apply.
+func NewJwtClientWithProvider(conf *Config, provider IKeyProvider) (client *JwtClient, err error) {
+	client = &JwtClient{
+		conf:         conf,
+		keyProvider:  provider,
+		refreshStore: coder.NewMemoryNonceStore(),
+	}
+
+	if conf != nil && conf.Encryption != nil {
+		if client.encrypter, err = NewEncrypter(conf.Encryption); err != nil {
+			return nil, err
+		}
+	}
+
 	return
 }
 
 func GetKeyProvider(conf *Config) (provider IKeyProvider, err error) {
+	// file and env references are read once, use NewReloadKeyProvider to follow changes
+	if hasKeyRef(conf) {
+		resolved, err := (&KeyLoader{}).ResolveConfig(conf)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return GetKeyProvider(resolved)
+	}
+
+	if len(conf.Keys) > 0 {
+		provider, err = newMultiKeyProvider(conf)
+		return
+	}
+
+	// a single key with kid is a key set of one, so the kid ends up in the header
+	if conf.Kid != "" && conf.SignType != SignTypeJwks {
+		single := *conf
+		single.Kid = ""
+
+		if provider, err = GetKeyProvider(&single); err != nil {
+			return nil, err
+		}
+
+		multi := NewMultiKeyProvider()
+		multi.AddKey(conf.Kid, provider)
+		return multi, nil
+	}
+
 	if conf.SignType == SignTypeHS {
 		provider, err = newHsTokenProducer(conf.PrivateKey, conf.SignMethod)
 		return
@@ -63,6 +176,19 @@ func GetKeyProvider(conf *Config) (provider IKeyProvider, err error) {
 	} else if conf.SignType == SignTypeES {
 		provider, err = newEsTokenProducer(conf.PrivateKey, conf.PublicKey, conf.SignMethod)
 		return
+	} else if conf.SignType == SignTypePS {
+		provider, err = newPsTokenProducer(conf.PrivateKey, conf.PublicKey, conf.SignMethod)
+		return
+	} else if conf.SignType == SignTypeEd {
+		provider, err = newEdTokenProducer(conf.PrivateKey, conf.PublicKey)
+		return
+	} else if conf.SignType == SignTypeJwks {
+		if conf.JwksUrl == "" {
+			return nil, errors.New("jwks url is empty")
+		}
+
+		provider = NewJwksKeyProvider(conf.JwksUrl, 5*time.Second)
+		return
 	}
 
 	return nil, errors.New("sign type not valid")
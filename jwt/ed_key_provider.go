@@ -0,0 +1,78 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"errors"
+	gjwt "github.com/golang-jwt/jwt/v4"
+)
+
+/*
+EdDSA = Ed25519 签名
+
+密钥固定 32 字节，签名 64 字节，不依赖随机数，只有 EdDSA 一种签名方法。
+*/
+
+const (
+	SignMethodEdDSA = "EdDSA"
+)
+
+type EdTokenProducer struct {
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+}
+
+func (p *EdTokenProducer) GetSigningMethod() gjwt.SigningMethod {
+	return gjwt.SigningMethodEdDSA
+}
+
+func (p *EdTokenProducer) GetPrivateKey() any {
+	return p.privateKey
+}
+
+func (p *EdTokenProducer) GetPublicKey(token *gjwt.Token) (interface{}, error) {
+	return p.publicKey, nil
+}
+
+func (p *EdTokenProducer) GetPublicKeys() []*PublicKey {
+	return []*PublicKey{{Alg: SignMethodEdDSA, Key: p.publicKey}}
+}
+
+func newEdTokenProducer(privateKeyString, publicKeyString string) (result *EdTokenProducer, err error) {
+	privateKey, err := gjwt.ParseEdPrivateKeyFromPEM([]byte(privateKeyString))
+	if err != nil {
+		return
+	}
+
+	publicKey, err := gjwt.ParseEdPublicKeyFromPEM([]byte(publicKeyString))
+	if err != nil {
+		return
+	}
+
+	result = &EdTokenProducer{}
+
+	if result.privateKey, err = toEd25519PrivateKey(privateKey); err != nil {
+		return nil, err
+	}
+
+	if result.publicKey, err = toEd25519PublicKey(publicKey); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+func toEd25519PrivateKey(key any) (ed25519.PrivateKey, error) {
+	if k, ok := key.(ed25519.PrivateKey); ok {
+		return k, nil
+	}
+
+	return nil, errors.New("key is not a valid ed25519 private key")
+}
+
+func toEd25519PublicKey(key any) (ed25519.PublicKey, error) {
+	if k, ok := key.(ed25519.PublicKey); ok {
+		return k, nil
+	}
+
+	return nil, errors.New("key is not a valid ed25519 public key")
+}
@@ -0,0 +1,162 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"github.com/go-jose/go-jose/v3"
+	gjwt "github.com/golang-jwt/jwt/v4"
+	"strings"
+)
+
+const (
+	KeyAlgRsaOaep      = string(jose.RSA_OAEP)
+	KeyAlgRsaOaep256   = string(jose.RSA_OAEP_256)
+	KeyAlgEcdhEs       = string(jose.ECDH_ES)
+	KeyAlgEcdhEsA256KW = string(jose.ECDH_ES_A256KW)
+)
+
+const ContentTypeJwt = "JWT"
+
+var ErrTokenNotEncrypted = errors.New("token is not encrypted")
+
+// EncryptionConfig wraps signed tokens in a JWE with A256GCM content encryption.
+// PublicKey is the recipient key used by GenerateToken, PrivateKey decrypts in ParseToken,
+// a client can hold either or both. KeyAlgorithm defaults to RSA-OAEP for RSA keys and ECDH-ES for EC keys.
+type EncryptionConfig struct {
+	PrivateKey   string `toml:"private_key" json:"private_key" yaml:"private_key"`
+	PublicKey    string `toml:"public_key" json:"public_key" yaml:"public_key"`
+	KeyAlgorithm string `toml:"key_algorithm" json:"key_algorithm" yaml:"key_algorithm"`
+}
+
+// Encrypter nests signed tokens in compact JWEs with cty JWT (RFC 7519 section 5.2).
+type Encrypter struct {
+	keyAlgorithm jose.KeyAlgorithm
+	publicKey    any
+	privateKey   any
+}
+
+func (e *Encrypter) Encrypt(tokenString string) (string, error) {
+	if e.publicKey == nil {
+		return "", errors.New("no encryption public key")
+	}
+
+	options := (&jose.EncrypterOptions{}).WithContentType(ContentTypeJwt)
+	encrypter, err := jose.NewEncrypter(jose.A256GCM, jose.Recipient{Algorithm: e.keyAlgorithm, Key: e.publicKey}, options)
+
+	if err != nil {
+		return "", err
+	}
+
+	object, err := encrypter.Encrypt([]byte(tokenString))
+
+	if err != nil {
+		return "", err
+	}
+
+	return object.CompactSerialize()
+}
+
+// Decrypt returns the nested signed token, it does not verify the signature.
+func (e *Encrypter) Decrypt(tokenString string) (string, error) {
+	if e.privateKey == nil {
+		return "", errors.New("no encryption private key")
+	}
+
+	if strings.Count(tokenString, ".") != 4 {
+		return "", ErrTokenNotEncrypted
+	}
+
+	object, err := jose.ParseEncrypted(tokenString)
+
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrTokenMalformed, err)
+	}
+
+	if object.Header.Algorithm != string(e.keyAlgorithm) {
+		return "", fmt.Errorf("key algorithm %s not allowed", object.Header.Algorithm)
+	}
+
+	data, err := object.Decrypt(e.privateKey)
+
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrTokenUnverifiable, err)
+	}
+
+	return string(data), nil
+}
+
+func NewEncrypter(conf *EncryptionConfig) (result *Encrypter, err error) {
+	result = &Encrypter{keyAlgorithm: jose.KeyAlgorithm(conf.KeyAlgorithm)}
+	var isRsa bool
+
+	if conf.PublicKey != "" {
+		if result.publicKey, err = gjwt.ParseRSAPublicKeyFromPEM([]byte(conf.PublicKey)); err == nil {
+			isRsa = true
+		} else if result.publicKey, err = gjwt.ParseECPublicKeyFromPEM([]byte(conf.PublicKey)); err != nil {
+			return nil, errors.New("encryption public key is neither rsa nor ec")
+		}
+	}
+
+	if conf.PrivateKey != "" {
+		var rsaKey *rsa.PrivateKey
+		var ecKey *ecdsa.PrivateKey
+
+		if rsaKey, err = gjwt.ParseRSAPrivateKeyFromPEM([]byte(conf.PrivateKey)); err == nil {
+			result.privateKey, isRsa = rsaKey, true
+		} else if ecKey, err = gjwt.ParseECPrivateKeyFromPEM([]byte(conf.PrivateKey)); err == nil {
+			result.privateKey = ecKey
+		} else {
+			return nil, errors.New("encryption private key is neither rsa nor ec")
+		}
+	}
+
+	if result.publicKey == nil && result.privateKey == nil {
+		return nil, errors.New("no encryption key")
+	}
+
+	if result.keyAlgorithm == "" {
+		result.keyAlgorithm = jose.ECDH_ES
+
+		if isRsa {
+			result.keyAlgorithm = jose.RSA_OAEP
+		}
+	}
+
+	switch string(result.keyAlgorithm) {
+	case KeyAlgRsaOaep, KeyAlgRsaOaep256:
+		if !isRsa {
+			return nil, fmt.Errorf("key algorithm %s needs a rsa key", result.keyAlgorithm)
+		}
+	case KeyAlgEcdhEs, KeyAlgEcdhEsA256KW:
+		if isRsa {
+			return nil, fmt.Errorf("key algorithm %s needs an ec key", result.keyAlgorithm)
+		}
+	default:
+		return nil, fmt.Errorf("key algorithm %s not supported", result.keyAlgorithm)
+	}
+
+	return result, nil
+}
+
+// SetEncrypter turns on nested tokens, nil turns them off. Encrypted clients reject plain signed tokens.
+func (c *JwtClient) SetEncrypter(encrypter *Encrypter) {
+	c.encrypter = encrypter
+}
+
+func (c *JwtClient) encrypt(tokenString string) (string, error) {
+	if c.encrypter == nil {
+		return tokenString, nil
+	}
+
+	return c.encrypter.Encrypt(tokenString)
+}
+
+func (c *JwtClient) decrypt(tokenString string) (string, error) {
+	if c.encrypter == nil {
+		return tokenString, nil
+	}
+
+	return c.encrypter.Decrypt(tokenString)
+}
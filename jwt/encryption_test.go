@@ -0,0 +1,79 @@
+package jwt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestJwtClient_Encryption(t *testing.T) {
+	rsa := newRsaKeyConfig(t, "")
+	cases := []*EncryptionConfig{
+		{PrivateKey: rsa.PrivateKey, PublicKey: rsa.PublicKey},
+		{PrivateKey: rsa.PrivateKey, PublicKey: rsa.PublicKey, KeyAlgorithm: KeyAlgRsaOaep256},
+		{PrivateKey: conf.PrivateKey, PublicKey: conf.PublicKey},
+		{PrivateKey: conf.PrivateKey, PublicKey: conf.PublicKey, KeyAlgorithm: KeyAlgEcdhEsA256KW},
+	}
+
+	for i, c := range cases {
+		client, err := NewJwtClient(&Config{PrivateKey: conf.PrivateKey, PublicKey: conf.PublicKey, SignType: SignTypeES, Encryption: c})
+
+		if err != nil {
+			t.Fatalf("case %d new jwt client fail. | err: %s", i, err)
+		}
+
+		claims := &pairClaims{Name: "secret name"}
+		pair, err := client.IssueTokenPair(claims)
+
+		if err != nil {
+			t.Fatalf("case %d issue token pair fail. | err: %s", i, err)
+		}
+
+		if strings.Count(pair.AccessToken, ".") != 4 {
+			t.Fatalf("case %d token not encrypted. | token: %s", i, pair.AccessToken)
+		}
+
+		parsed := &pairClaims{}
+		token, err := client.ParseAccessToken(pair.AccessToken, parsed)
+
+		if err != nil || parsed.Name != "secret name" || token.Method.Alg() != SignMethodES256 {
+			t.Fatalf("case %d parse token fail. | claims: %+v | err: %v", i, parsed, err)
+		}
+
+		if _, err = client.Refresh(pair.RefreshToken, &pairClaims{}); err != nil {
+			t.Fatalf("case %d refresh fail. | err: %s", i, err)
+		}
+	}
+
+	plain, _ := NewJwtClient(&Config{PrivateKey: conf.PrivateKey, PublicKey: conf.PublicKey, SignType: SignTypeES})
+	encrypted, _ := NewJwtClient(&Config{PrivateKey: conf.PrivateKey, PublicKey: conf.PublicKey, SignType: SignTypeES, Encryption: cases[0]})
+	tokenString, _ := plain.GenerateToken(newRotationClaims())
+
+	if _, err := encrypted.ParseToken(tokenString, &pairClaims{}); !errors.Is(err, ErrTokenNotEncrypted) {
+		t.Fatalf("plain token accepted. | err: %v", err)
+	}
+
+	// a provider built at runtime still encrypts
+	provider, _ := GetKeyProvider(&Config{PrivateKey: conf.PrivateKey, PublicKey: conf.PublicKey, SignType: SignTypeES})
+	withProvider, err := NewJwtClientWithProvider(&Config{Encryption: cases[0]}, provider)
+
+	if err != nil {
+		t.Fatalf("new jwt client with provider fail. | err: %s", err)
+	}
+
+	if tokenString, _ = withProvider.GenerateToken(newRotationClaims()); strings.Count(tokenString, ".") != 4 {
+		t.Fatalf("token of provider client not encrypted. | token: %s", tokenString)
+	}
+
+	if _, err = withProvider.ParseToken(tokenString, &pairClaims{}); err != nil {
+		t.Fatalf("parse token of provider client fail. | err: %s", err)
+	}
+
+	if _, err = NewJwtClientWithProvider(&Config{Encryption: &EncryptionConfig{PublicKey: "invalid"}}, provider); err == nil {
+		t.Fatalf("invalid encryption config accepted")
+	}
+
+	if _, err := NewEncrypter(&EncryptionConfig{PublicKey: rsa.PublicKey, KeyAlgorithm: KeyAlgEcdhEs}); err == nil {
+		t.Fatalf("mismatched key algorithm accepted")
+	}
+}
@@ -0,0 +1,272 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dylanpeng/golib/coder"
+	"github.com/gin-gonic/gin"
+	"math/big"
+	"sort"
+)
+
+const JwksPath = "/.well-known/jwks.json"
+
+const (
+	KeyTypeRSA = "RSA"
+	KeyTypeEC  = "EC"
+	KeyTypeOKP = "OKP"
+)
+
+const CurveEd25519 = "Ed25519"
+
+// PublicKey is a verification key with its id and algorithm.
+type PublicKey struct {
+	Kid string
+	Alg string
+	Key crypto.PublicKey
+}
+
+// IPublicKeySet is implemented by providers whose public keys can be published.
+type IPublicKeySet interface {
+	GetPublicKeys() []*PublicKey
+}
+
+// Jwk is a JSON Web Key (RFC 7517) holding a RSA, EC or Ed25519 (RFC 8037) public key.
+type Jwk struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type Jwks struct {
+	Keys []*Jwk `json:"keys"`
+}
+
+func (s *Jwks) Get(kid string) (*Jwk, bool) {
+	for _, key := range s.Keys {
+		if key.Kid == kid {
+			return key, true
+		}
+	}
+
+	return nil, false
+}
+
+// PublicKey decodes the jwk to *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey.
+func (k *Jwk) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case KeyTypeRSA:
+		n, err := decodeBigInt(k.N)
+
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case KeyTypeEC:
+		curve, ok := curves[k.Crv]
+
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("ec point not on curve")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case KeyTypeOKP:
+		if k.Crv != CurveEd25519 {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 public key size")
+		}
+
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+// Thumbprint is the RFC 7638 SHA-256 thumbprint, used as kid when none is configured.
+func (k *Jwk) Thumbprint() string {
+	var data string
+
+	if k.Kty == KeyTypeRSA {
+		data = fmt.Sprintf(`{"e":"%s","kty":"%s","n":"%s"}`, k.E, k.Kty, k.N)
+	} else if k.Kty == KeyTypeOKP {
+		data = fmt.Sprintf(`{"crv":"%s","kty":"%s","x":"%s"}`, k.Crv, k.Kty, k.X)
+	} else {
+		data = fmt.Sprintf(`{"crv":"%s","kty":"%s","x":"%s","y":"%s"}`, k.Crv, k.Kty, k.X, k.Y)
+	}
+
+	sum := sha256.Sum256([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+var curves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+func NewJwk(kid, alg string, key crypto.PublicKey) (*Jwk, error) {
+	result := &Jwk{Use: "sig", Kid: kid, Alg: alg}
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		result.Kty = KeyTypeRSA
+		result.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+		result.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		result.Kty = KeyTypeEC
+		result.Crv = k.Curve.Params().Name
+		result.X = base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, size)))
+		result.Y = base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		result.Kty = KeyTypeOKP
+		result.Crv = CurveEd25519
+		result.X = base64.RawURLEncoding.EncodeToString(k)
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+
+	if result.Kid == "" {
+		result.Kid = result.Thumbprint()
+	}
+
+	return result, nil
+}
+
+// ExportJwks renders the asymmetric public keys of provider, HS secrets are never published.
+func ExportJwks(provider IKeyProvider) (*Jwks, error) {
+	result := &Jwks{Keys: make([]*Jwk, 0)}
+	set, ok := provider.(IPublicKeySet)
+
+	if !ok {
+		return result, nil
+	}
+
+	for _, key := range set.GetPublicKeys() {
+		jwk, err := NewJwk(key.Kid, key.Alg, key.Key)
+
+		if err != nil {
+			continue
+		}
+
+		result.Keys = append(result.Keys, jwk)
+	}
+
+	return result, nil
+}
+
+// JwksHandler serves the provider key set, register it on JwksPath.
+// The set is rendered per request so keys added or retired at runtime show up immediately.
+func JwksHandler(provider IKeyProvider) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		jwks, err := ExportJwks(provider)
+
+		if err != nil {
+			_ = coder.SendError(ctx, coder.JsonCoder, err)
+			return
+		}
+
+		ctx.Header("Cache-Control", "public, max-age=300")
+		_ = coder.JsonCoder.SendResponse(ctx, jwks)
+	}
+}
+
+func ParseJwks(data []byte) (*Jwks, error) {
+	result := &Jwks{}
+
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (p *RsTokenProducer) GetPublicKeys() []*PublicKey {
+	return []*PublicKey{{Alg: p.GetSigningMethod().Alg(), Key: p.publicKey}}
+}
+
+func (p *EsTokenProducer) GetPublicKeys() []*PublicKey {
+	return []*PublicKey{{Alg: p.GetSigningMethod().Alg(), Key: p.publicKey}}
+}
+
+// GetPublicKeys returns the keys of every active and retired kid, sorted by kid.
+func (p *MultiKeyProvider) GetPublicKeys() []*PublicKey {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+
+	result := make([]*PublicKey, 0, len(p.keys))
+
+	for kid, entry := range p.keys {
+		set, ok := entry.provider.(IPublicKeySet)
+
+		if !ok {
+			continue
+		}
+
+		for _, key := range set.GetPublicKeys() {
+			result = append(result, &PublicKey{Kid: kid, Alg: key.Alg, Key: key.Key})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Kid < result[j].Kid
+	})
+
+	return result
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
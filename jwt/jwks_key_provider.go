@@ -0,0 +1,192 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	oHttp "github.com/dylanpeng/golib/http"
+	gjwt "github.com/golang-jwt/jwt/v4"
+	"sync"
+	"time"
+)
+
+const SignTypeJwks = "JWKS"
+
+var ErrKidNotFound = errors.New("kid not found in jwks")
+
+type jwksKey struct {
+	methods []string
+	key     any
+}
+
+// JwksKeyProvider verifies tokens against the key set published at a remote url, it can not sign.
+// The set is cached for CacheTtl and refetched early when a token carries an unknown kid,
+// but never more often than once per MinRefreshInterval.
+type JwksKeyProvider struct {
+	url                string
+	client             *oHttp.Client
+	CacheTtl           time.Duration
+	MinRefreshInterval time.Duration
+
+	locker    sync.RWMutex
+	keys      map[string]*jwksKey
+	fetchedAt time.Time
+
+	fetchLocker sync.Mutex
+	attemptedAt time.Time
+}
+
+func (p *JwksKeyProvider) GetSigningMethod() gjwt.SigningMethod {
+	return nil
+}
+
+func (p *JwksKeyProvider) GetPrivateKey() any {
+	return nil
+}
+
+// GetValidMethods lists the algorithms of the fetched keys, the set is fetched first when it is empty or stale.
+func (p *JwksKeyProvider) GetValidMethods() []string {
+	p.locker.RLock()
+	empty, stale := len(p.keys) == 0, time.Since(p.fetchedAt) > p.CacheTtl
+	p.locker.RUnlock()
+
+	if empty || stale {
+		_ = p.refresh(false)
+	}
+
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+
+	result := make([]string, 0, len(p.keys))
+	seen := make(map[string]bool)
+
+	for _, entry := range p.keys {
+		for _, method := range entry.methods {
+			if !seen[method] {
+				seen[method] = true
+				result = append(result, method)
+			}
+		}
+	}
+
+	return result
+}
+
+func (p *JwksKeyProvider) GetPublicKey(token *gjwt.Token) (interface{}, error) {
+	kid, _ := token.Header[HeaderKid].(string)
+
+	p.locker.RLock()
+	entry, ok := p.keys[kid]
+	stale := time.Since(p.fetchedAt) > p.CacheTtl
+	p.locker.RUnlock()
+
+	if !ok || stale {
+		// a failed refetch keeps serving the cached set
+		if err := p.refresh(false); err != nil && !ok {
+			return nil, err
+		}
+
+		p.locker.RLock()
+		entry, ok = p.keys[kid]
+		p.locker.RUnlock()
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKidNotFound, kid)
+	}
+
+	if !hasMethod(entry.methods, token.Method.Alg()) {
+		return nil, fmt.Errorf("%w: %s for kid %s", ErrTokenMethodNotAllowed, token.Method.Alg(), kid)
+	}
+
+	return entry.key, nil
+}
+
+// Refresh fetches the key set now, regardless of the rate limit.
+func (p *JwksKeyProvider) Refresh() error {
+	return p.refresh(true)
+}
+
+func (p *JwksKeyProvider) refresh(force bool) error {
+	p.fetchLocker.Lock()
+	defer p.fetchLocker.Unlock()
+
+	if !force && time.Since(p.attemptedAt) < p.MinRefreshInterval {
+		return nil
+	}
+
+	p.attemptedAt = time.Now()
+	_, body, err := p.client.Get(p.url, map[string]string{"Accept": "application/json"}, nil)
+
+	if err != nil {
+		return fmt.Errorf("fetch jwks fail: %w", err)
+	}
+
+	jwks, err := ParseJwks(body)
+
+	if err != nil {
+		return fmt.Errorf("parse jwks fail: %w", err)
+	}
+
+	keys := make(map[string]*jwksKey, len(jwks.Keys))
+
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.PublicKey()
+
+		if err != nil {
+			continue
+		}
+
+		keys[jwk.Kid] = &jwksKey{methods: jwkMethods(jwk, key), key: key}
+	}
+
+	p.locker.Lock()
+	p.keys = keys
+	p.fetchedAt = time.Now()
+	p.locker.Unlock()
+
+	return nil
+}
+
+// jwkMethods returns the algorithms a key verifies, its alg when published or all algorithms of its key type.
+func jwkMethods(jwk *Jwk, key crypto.PublicKey) []string {
+	if jwk.Alg != "" {
+		return []string{jwk.Alg}
+	}
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return []string{SignMethodRS256, SignMethodRS384, SignMethodRS512, SignMethodPS256, SignMethodPS384, SignMethodPS512}
+	case *ecdsa.PublicKey:
+		switch k.Curve.Params().BitSize {
+		case 256:
+			return []string{SignMethodES256}
+		case 384:
+			return []string{SignMethodES384}
+		case 521:
+			return []string{SignMethodES512}
+		}
+	case ed25519.PublicKey:
+		return []string{SignMethodEdDSA}
+	}
+
+	return nil
+}
+
+// NewJwksKeyProvider creates a provider for url, the key set is fetched on first use.
+func NewJwksKeyProvider(url string, timeout time.Duration) *JwksKeyProvider {
+	return &JwksKeyProvider{
+		url:                url,
+		client:             oHttp.NewClient(timeout),
+		CacheTtl:           time.Hour,
+		MinRefreshInterval: time.Minute,
+		keys:               make(map[string]*jwksKey),
+	}
+}
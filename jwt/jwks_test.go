@@ -0,0 +1,194 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newRsaKeyConfig(t *testing.T, kid string) *KeyConfig {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatalf("generate rsa key fail. | err: %s", err)
+	}
+
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+
+	if err != nil {
+		t.Fatalf("marshal public key fail. | err: %s", err)
+	}
+
+	return &KeyConfig{
+		Kid:        kid,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})),
+		SignType:   SignTypeRS,
+		SignMethod: SignMethodRS256,
+	}
+}
+
+func TestExportJwks(t *testing.T) {
+	provider, err := GetKeyProvider(&Config{PrivateKey: conf.PrivateKey, PublicKey: conf.PublicKey, SignType: SignTypeES})
+
+	if err != nil {
+		t.Fatalf("get key provider fail. | err: %s", err)
+	}
+
+	jwks, err := ExportJwks(provider)
+
+	if err != nil || len(jwks.Keys) != 1 {
+		t.Fatalf("export jwks fail. | jwks: %v | err: %v", jwks, err)
+	}
+
+	jwk := jwks.Keys[0]
+
+	if jwk.Kty != KeyTypeEC || jwk.Crv != "P-256" || jwk.Alg != SignMethodES256 || jwk.Kid != jwk.Thumbprint() {
+		t.Fatalf("unexpected jwk. | jwk: %+v", jwk)
+	}
+
+	if _, err = jwk.PublicKey(); err != nil {
+		t.Fatalf("decode jwk fail. | err: %s", err)
+	}
+
+	hs, _ := GetKeyProvider(&Config{PrivateKey: "secret", SignType: SignTypeHS, SignMethod: SignMethodHS256})
+
+	if jwks, _ = ExportJwks(hs); len(jwks.Keys) != 0 {
+		t.Fatalf("hs keys must not be published. | keys: %d", len(jwks.Keys))
+	}
+}
+
+func TestJwksKeyProvider(t *testing.T) {
+	issuer := NewMultiKeyProvider()
+
+	if err := issuer.AddKeyConfig(newRsaKeyConfig(t, "rs-1")); err != nil {
+		t.Fatalf("add key fail. | err: %s", err)
+	}
+
+	var fetches int32
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := JwksHandler(issuer)
+	router.GET(JwksPath, func(ctx *gin.Context) {
+		atomic.AddInt32(&fetches, 1)
+		handler(ctx)
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	client, err := NewJwtClient(&Config{SignType: SignTypeJwks, JwksUrl: server.URL + JwksPath})
+
+	if err != nil {
+		t.Fatalf("new jwks client fail. | err: %s", err)
+	}
+
+	signer, err := NewJwtClientWithProvider(nil, issuer)
+
+	if err != nil {
+		t.Fatalf("new signer fail. | err: %s", err)
+	}
+
+	tokenString, err := signer.GenerateToken(newRotationClaims())
+
+	if err != nil {
+		t.Fatalf("generate token fail. | err: %s", err)
+	}
+
+	if _, err = client.ParseToken(tokenString, &jwt.RegisteredClaims{}); err != nil {
+		t.Fatalf("parse token fail. | err: %s", err)
+	}
+
+	// a new key is picked up by the refresh on unknown kid
+	if err = issuer.AddKeyConfig(newRsaKeyConfig(t, "rs-2")); err != nil {
+		t.Fatalf("add key fail. | err: %s", err)
+	}
+
+	_ = issuer.SetCurrent("rs-2")
+	client.GetKeyProvider().(*JwksKeyProvider).MinRefreshInterval = 0
+
+	if tokenString, err = signer.GenerateToken(newRotationClaims()); err != nil {
+		t.Fatalf("generate token fail. | err: %s", err)
+	}
+
+	if _, err = client.ParseToken(tokenString, &jwt.RegisteredClaims{}); err != nil {
+		t.Fatalf("parse rotated token fail. | err: %s", err)
+	}
+
+	if fetches != 2 {
+		t.Fatalf("unexpected fetch count. | fetches: %d", fetches)
+	}
+
+	// unknown kids do not refetch within the rate limit
+	client.GetKeyProvider().(*JwksKeyProvider).MinRefreshInterval = time.Minute
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, newRotationClaims())
+	token.Header[HeaderKid] = "unknown"
+	provider, _ := issuer.GetProvider("rs-2")
+	tokenString, _ = token.SignedString(provider.GetPrivateKey())
+
+	for i := 0; i < 3; i++ {
+		if _, err = client.ParseToken(tokenString, &jwt.RegisteredClaims{}); !errors.Is(err, ErrKidNotFound) {
+			t.Fatalf("unknown kid must be rejected. | err: %v", err)
+		}
+	}
+
+	if fetches != 2 {
+		t.Fatalf("refetch not rate limited. | fetches: %d", fetches)
+	}
+}
+
+func TestJwksKeyProvider_ValidMethods(t *testing.T) {
+	issuer := NewMultiKeyProvider()
+
+	if err := issuer.AddKeyConfig(newRsaKeyConfig(t, "rs-1")); err != nil {
+		t.Fatalf("add key fail. | err: %s", err)
+	}
+
+	// a set published without alg
+	jwks, _ := ExportJwks(issuer)
+	jwks.Keys[0].Alg = ""
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET(JwksPath, func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, jwks)
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	client, err := NewJwtClient(&Config{SignType: SignTypeJwks, JwksUrl: server.URL + JwksPath})
+
+	if err != nil {
+		t.Fatalf("new jwks client fail. | err: %s", err)
+	}
+
+	provider, _ := issuer.GetProvider("rs-1")
+
+	for _, c := range []struct {
+		method jwt.SigningMethod
+		key    any
+		expect error
+	}{
+		{jwt.SigningMethodPS256, provider.GetPrivateKey(), nil},
+		{jwt.SigningMethodHS256, []byte("secret"), ErrTokenMethodNotAllowed},
+	} {
+		token := jwt.NewWithClaims(c.method, newRotationClaims())
+		token.Header[HeaderKid] = "rs-1"
+		tokenString, _ := token.SignedString(c.key)
+
+		if _, err = client.ParseToken(tokenString, &jwt.RegisteredClaims{}); !errors.Is(err, c.expect) {
+			t.Fatalf("%s unexpected error. | expect: %v | err: %v", c.method.Alg(), c.expect, err)
+		}
+	}
+
+	if methods := client.GetKeyProvider().(*JwksKeyProvider).GetValidMethods(); len(methods) != 6 {
+		t.Fatalf("unexpected valid methods. | methods: %v", methods)
+	}
+}
@@ -0,0 +1,378 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dylanpeng/golib/etcd"
+	"github.com/dylanpeng/golib/logger"
+	gjwt "github.com/golang-jwt/jwt/v4"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Key references accepted in PrivateKey and PublicKey instead of inline PEM.
+const (
+	KeySourceFile = "file://"
+	KeySourceEnv  = "env://"
+	KeySourceEtcd = "etcd://"
+)
+
+// KeyLoader resolves key references, Etcd is only needed for etcd:// references.
+// etcd values are raw PEM or a JSON string as written by etcd.Client.AddNode.
+type KeyLoader struct {
+	Etcd *etcd.Client
+}
+
+func IsKeyRef(value string) bool {
+	return strings.HasPrefix(value, KeySourceFile) || strings.HasPrefix(value, KeySourceEnv) || strings.HasPrefix(value, KeySourceEtcd)
+}
+
+// Load returns the key material of ref, values that are not references are returned as they are.
+func (l *KeyLoader) Load(ref string) (string, error) {
+	if path, ok := strings.CutPrefix(ref, KeySourceFile); ok {
+		data, err := os.ReadFile(path)
+
+		if err != nil {
+			return "", fmt.Errorf("read key file fail: %w", err)
+		}
+
+		return string(data), nil
+	}
+
+	if name, ok := strings.CutPrefix(ref, KeySourceEnv); ok {
+		value, exists := os.LookupEnv(name)
+
+		if !exists {
+			return "", fmt.Errorf("key env %s not set", name)
+		}
+
+		return value, nil
+	}
+
+	if key, ok := strings.CutPrefix(ref, KeySourceEtcd); ok {
+		if l.Etcd == nil {
+			return "", errors.New("etcd key source needs an etcd client")
+		}
+
+		rsp, err := l.Etcd.GetEtcdClient().Get(context.Background(), key)
+
+		if err != nil {
+			return "", err
+		}
+
+		if len(rsp.Kvs) == 0 {
+			return "", fmt.Errorf("key %s not exist in etcd", key)
+		}
+
+		value := string(rsp.Kvs[0].Value)
+
+		if strings.HasPrefix(value, `"`) {
+			_ = json.Unmarshal(rsp.Kvs[0].Value, &value)
+		}
+
+		return value, nil
+	}
+
+	return ref, nil
+}
+
+// ResolveConfig returns a copy of conf with every key reference replaced by its key material.
+func (l *KeyLoader) ResolveConfig(conf *Config) (result *Config, err error) {
+	copied := *conf
+	result = &copied
+
+	if result.PrivateKey, err = l.Load(conf.PrivateKey); err != nil {
+		return nil, err
+	}
+
+	if result.PublicKey, err = l.Load(conf.PublicKey); err != nil {
+		return nil, err
+	}
+
+	result.Keys = make([]*KeyConfig, 0, len(conf.Keys))
+
+	for _, key := range conf.Keys {
+		k := *key
+
+		if k.PrivateKey, err = l.Load(key.PrivateKey); err != nil {
+			return nil, fmt.Errorf("key %s: %w", key.Kid, err)
+		}
+
+		if k.PublicKey, err = l.Load(key.PublicKey); err != nil {
+			return nil, fmt.Errorf("key %s: %w", key.Kid, err)
+		}
+
+		result.Keys = append(result.Keys, &k)
+	}
+
+	return
+}
+
+func hasKeyRef(conf *Config) bool {
+	if IsKeyRef(conf.PrivateKey) || IsKeyRef(conf.PublicKey) {
+		return true
+	}
+
+	for _, key := range conf.Keys {
+		if IsKeyRef(key.PrivateKey) || IsKeyRef(key.PublicKey) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getEtcdRefs(conf *Config) []string {
+	refs := make([]string, 0)
+
+	for _, value := range []string{conf.PrivateKey, conf.PublicKey} {
+		if key, ok := strings.CutPrefix(value, KeySourceEtcd); ok {
+			refs = append(refs, key)
+		}
+	}
+
+	for _, k := range conf.Keys {
+		for _, value := range []string{k.PrivateKey, k.PublicKey} {
+			if key, ok := strings.CutPrefix(value, KeySourceEtcd); ok {
+				refs = append(refs, key)
+			}
+		}
+	}
+
+	return refs
+}
+
+// ReloadKeyProvider rebuilds its provider when the referenced keys change. Files and env vars are
+// polled every interval, etcd keys are watched through the etcd client of the loader. A failed reload keeps the previous keys.
+type ReloadKeyProvider struct {
+	conf     *Config
+	loader   *KeyLoader
+	logger   logger.ILogger
+	interval time.Duration
+
+	locker   sync.RWMutex
+	provider IKeyProvider
+	snapshot string
+
+	reloadLocker sync.Mutex
+	stop         chan struct{}
+	cancel       context.CancelFunc
+	closeOnce    sync.Once
+}
+
+func (p *ReloadKeyProvider) get() IKeyProvider {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+
+	return p.provider
+}
+
+func (p *ReloadKeyProvider) GetSigningMethod() gjwt.SigningMethod {
+	return p.get().GetSigningMethod()
+}
+
+func (p *ReloadKeyProvider) GetPrivateKey() any {
+	return p.get().GetPrivateKey()
+}
+
+func (p *ReloadKeyProvider) GetPublicKey(token *gjwt.Token) (interface{}, error) {
+	return p.get().GetPublicKey(token)
+}
+
+func (p *ReloadKeyProvider) GetSigningKey() (kid string, method gjwt.SigningMethod, key any) {
+	provider := p.get()
+
+	if kidProvider, ok := provider.(IKidKeyProvider); ok {
+		return kidProvider.GetSigningKey()
+	}
+
+	return "", provider.GetSigningMethod(), provider.GetPrivateKey()
+}
+
+func (p *ReloadKeyProvider) GetValidMethods() []string {
+	return getValidMethods(p.get())
+}
+
+func (p *ReloadKeyProvider) GetPublicKeys() []*PublicKey {
+	if set, ok := p.get().(IPublicKeySet); ok {
+		return set.GetPublicKeys()
+	}
+
+	return nil
+}
+
+// Reload loads the keys now and swaps the provider when they changed.
+func (p *ReloadKeyProvider) Reload() error {
+	p.reloadLocker.Lock()
+	defer p.reloadLocker.Unlock()
+
+	resolved, err := p.loader.ResolveConfig(p.conf)
+
+	if err != nil {
+		return err
+	}
+
+	snapshot, _ := json.Marshal(resolved)
+
+	if string(snapshot) == p.snapshot {
+		return nil
+	}
+
+	provider, err := GetKeyProvider(resolved)
+
+	if err != nil {
+		return err
+	}
+
+	p.locker.Lock()
+	p.provider = provider
+	p.locker.Unlock()
+
+	p.snapshot = string(snapshot)
+	return nil
+}
+
+func (p *ReloadKeyProvider) reload() {
+	if err := p.Reload(); err != nil {
+		p.errorf("reload jwt keys fail. | err: %s", err)
+	}
+}
+
+func (p *ReloadKeyProvider) errorf(format string, args ...any) {
+	if p.logger != nil {
+		p.logger.Errorf(format, args...)
+	}
+}
+
+// etcdRevision returns the etcd revision to watch after, 0 without etcd references.
+func (p *ReloadKeyProvider) etcdRevision() (int64, error) {
+	refs := getEtcdRefs(p.conf)
+
+	if len(refs) == 0 || p.loader.Etcd == nil {
+		return 0, nil
+	}
+
+	rsp, err := p.loader.Etcd.GetEtcdClient().Get(context.Background(), refs[0])
+
+	if err != nil {
+		return 0, err
+	}
+
+	return rsp.Header.Revision, nil
+}
+
+// start polls and watches etcd for changes made after revision.
+func (p *ReloadKeyProvider) start(revision int64) error {
+	if p.interval > 0 {
+		go func() {
+			ticker := time.NewTicker(p.interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					p.reload()
+				case <-p.stop:
+					return
+				}
+			}
+		}()
+	}
+
+	refs := getEtcdRefs(p.conf)
+
+	if len(refs) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	for _, key := range refs {
+		go p.watch(ctx, key, revision)
+	}
+
+	return nil
+}
+
+// watch reloads on changes of key made after revision until ctx is done. A watch closed by etcd,
+// e.g. after a compaction, is started again from the last revision seen.
+func (p *ReloadKeyProvider) watch(ctx context.Context, key string, revision int64) {
+	for {
+		for rsp := range p.loader.Etcd.GetEtcdClient().Watch(ctx, key, clientv3.WithRev(revision+1)) {
+			if err := rsp.Err(); err != nil {
+				p.errorf("watch jwt keys fail. | key: %s | revision: %d | err: %s", key, revision, err)
+
+				// changes up to the compaction are gone, load the current keys and go on from there
+				if rsp.CompactRevision > 0 {
+					revision = rsp.Header.Revision
+					p.reload()
+				}
+
+				continue
+			}
+
+			if rsp.Header.Revision > revision {
+				revision = rsp.Header.Revision
+			}
+
+			if len(rsp.Events) > 0 {
+				p.reload()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// Close stops polling and watching, it can be called more than once.
+func (p *ReloadKeyProvider) Close() {
+	p.closeOnce.Do(func() {
+		close(p.stop)
+
+		if p.cancel != nil {
+			p.cancel()
+		}
+	})
+}
+
+// NewReloadKeyProvider loads the keys of conf through loader and keeps them fresh, interval 0 disables polling.
+func NewReloadKeyProvider(conf *Config, loader *KeyLoader, interval time.Duration, logger logger.ILogger) (*ReloadKeyProvider, error) {
+	if loader == nil {
+		loader = &KeyLoader{}
+	}
+
+	p := &ReloadKeyProvider{
+		conf:     conf,
+		loader:   loader,
+		logger:   logger,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+
+	// the revision is read first, so a change made during the initial load is still watched
+	revision, err := p.etcdRevision()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.Reload(); err != nil {
+		return nil, err
+	}
+
+	if err = p.start(revision); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
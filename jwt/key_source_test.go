@@ -0,0 +1,185 @@
+package jwt
+
+import (
+	"context"
+	"github.com/dylanpeng/golib/etcd"
+	"github.com/golang-jwt/jwt/v4"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGetKeyProvider_KeyRef(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "public.pem")
+
+	if err := os.WriteFile(path, []byte(conf.PublicKey), 0600); err != nil {
+		t.Fatalf("write key file fail. | err: %s", err)
+	}
+
+	t.Setenv("GOLIB_JWT_TEST_KEY", conf.PrivateKey)
+	client, err := NewJwtClient(&Config{PrivateKey: KeySourceEnv + "GOLIB_JWT_TEST_KEY", PublicKey: KeySourceFile + path, SignType: SignTypeES})
+
+	if err != nil {
+		t.Fatalf("new jwt client fail. | err: %s", err)
+	}
+
+	tokenString, err := client.GenerateToken(newRotationClaims())
+
+	if err != nil {
+		t.Fatalf("generate token fail. | err: %s", err)
+	}
+
+	if _, err = client.ParseToken(tokenString, &jwt.RegisteredClaims{}); err != nil {
+		t.Fatalf("parse token fail. | err: %s", err)
+	}
+
+	if _, err = GetKeyProvider(&Config{PrivateKey: KeySourceEnv + "GOLIB_JWT_MISSING", SignType: SignTypeHS}); err == nil {
+		t.Fatalf("missing env key accepted")
+	}
+}
+
+func TestReloadKeyProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+
+	if err := os.WriteFile(path, []byte("secret-1"), 0600); err != nil {
+		t.Fatalf("write key file fail. | err: %s", err)
+	}
+
+	c := &Config{PrivateKey: KeySourceFile + path, SignType: SignTypeHS, SignMethod: SignMethodHS256}
+	provider, err := NewReloadKeyProvider(c, nil, 10*time.Millisecond, nil)
+
+	if err != nil {
+		t.Fatalf("new reload key provider fail. | err: %s", err)
+	}
+
+	defer provider.Close()
+	client, err := NewJwtClientWithProvider(c, provider)
+
+	if err != nil {
+		t.Fatalf("new jwt client fail. | err: %s", err)
+	}
+
+	oldToken, _ := client.GenerateToken(newRotationClaims())
+
+	if err = os.WriteFile(path, []byte("secret-2"), 0600); err != nil {
+		t.Fatalf("write key file fail. | err: %s", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+
+	for string(provider.GetPrivateKey().([]byte)) != "secret-2" {
+		if time.Now().After(deadline) {
+			t.Fatalf("key not reloaded")
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, err = client.ParseToken(oldToken, &jwt.RegisteredClaims{}); err == nil {
+		t.Fatalf("token of replaced key accepted")
+	}
+
+	// a broken source keeps the current key
+	_ = os.Remove(path)
+
+	if err = provider.Reload(); err == nil || string(provider.GetPrivateKey().([]byte)) != "secret-2" {
+		t.Fatalf("failed reload replaced the key. | err: %v", err)
+	}
+
+	provider.Close()
+}
+
+func TestReloadKeyProvider_Etcd(t *testing.T) {
+	client, err := etcd.NewClient(&etcd.Config{Addrs: []string{"127.0.0.1:2379"}, Timeout: 1})
+
+	if err != nil {
+		t.Skipf("etcd not available. | err: %s", err)
+	}
+
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	key := "/test/jwt/secret"
+
+	if _, err = client.GetEtcdClient().Put(ctx, key, "secret-1"); err != nil {
+		t.Skipf("etcd not available. | err: %s", err)
+	}
+
+	defer client.DeleteNode(key)
+
+	// change the key between the initial read and the watch, interval 0 leaves the watch as the only way to notice
+	provider := &ReloadKeyProvider{
+		conf:   &Config{PrivateKey: KeySourceEtcd + key, SignType: SignTypeHS, SignMethod: SignMethodHS256},
+		loader: &KeyLoader{Etcd: client},
+		stop:   make(chan struct{}),
+	}
+
+	revision, err := provider.etcdRevision()
+
+	if err != nil {
+		t.Fatalf("get etcd revision fail. | err: %s", err)
+	}
+
+	if err = provider.Reload(); err != nil {
+		t.Fatalf("load etcd key fail. | err: %s", err)
+	}
+
+	if err = client.AddNode(key, "secret-2"); err != nil {
+		t.Fatalf("update etcd key fail. | err: %s", err)
+	}
+
+	if err = provider.start(revision); err != nil {
+		t.Fatalf("start watch fail. | err: %s", err)
+	}
+
+	defer provider.Close()
+	deadline := time.Now().Add(2 * time.Second)
+
+	for string(provider.GetPrivateKey().([]byte)) != "secret-2" {
+		if time.Now().After(deadline) {
+			t.Fatalf("etcd key change before watch lost")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// a watch from a compacted revision loads the current keys and keeps watching
+	compacted := &ReloadKeyProvider{conf: provider.conf, loader: provider.loader, stop: make(chan struct{})}
+
+	if err = compacted.Reload(); err != nil {
+		t.Fatalf("load etcd key fail. | err: %s", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	rsp, err := client.GetEtcdClient().Put(ctx, key, "secret-3")
+
+	if err != nil {
+		t.Fatalf("update etcd key fail. | err: %s", err)
+	}
+
+	if _, err = client.GetEtcdClient().Compact(ctx, rsp.Header.Revision); err != nil {
+		t.Fatalf("compact etcd fail. | err: %s", err)
+	}
+
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go compacted.watch(watchCtx, key, revision)
+
+	for _, expect := range []string{"secret-3", "secret-4"} {
+		if expect == "secret-4" {
+			if err = client.AddNode(key, expect); err != nil {
+				t.Fatalf("update etcd key fail. | err: %s", err)
+			}
+		}
+
+		for deadline = time.Now().Add(2 * time.Second); string(compacted.GetPrivateKey().([]byte)) != expect; {
+			if time.Now().After(deadline) {
+				t.Fatalf("etcd key change after compaction lost. | expect: %s", expect)
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
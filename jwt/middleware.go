@@ -0,0 +1,233 @@
+package jwt
+
+import (
+	"errors"
+	"github.com/dylanpeng/golib/coder"
+	"github.com/gin-gonic/gin"
+	gjwt "github.com/golang-jwt/jwt/v4"
+	"net/http"
+	"strings"
+)
+
+const (
+	ContextClaimsKey = "golib/jwt/claims"
+	ContextTokenKey  = "golib/jwt/token"
+)
+
+const (
+	AuthorizationHeader = "Authorization"
+	SchemeBearer        = "Bearer"
+)
+
+var ErrTokenMissing = errors.New("token is missing")
+
+// IScopeClaims and IRoleClaims are implemented by claims that RequireScopes and RequireRoles can check.
+// TokenClaims implements both.
+type IScopeClaims interface {
+	GetScopes() []string
+}
+
+type IRoleClaims interface {
+	GetRoles() []string
+}
+
+// AuthConfig authenticates gin requests with a JwtClient. The token is read from the header first,
+// then the cookie, then the query param, empty names are not read.
+type AuthConfig struct {
+	Client     *JwtClient
+	NewClaims  func() gjwt.Claims
+	Header     string
+	Scheme     string
+	CookieName string
+	QueryName  string
+	Coder      coder.ICoder
+}
+
+// GetToken returns the raw token of the request, empty when none is sent.
+func (c *AuthConfig) GetToken(ctx *gin.Context) string {
+	if c.Header != "" {
+		value := strings.TrimSpace(ctx.GetHeader(c.Header))
+
+		if c.Scheme == "" {
+			if value != "" {
+				return value
+			}
+		} else if len(value) > len(c.Scheme) && strings.EqualFold(value[:len(c.Scheme)], c.Scheme) && value[len(c.Scheme)] == ' ' {
+			return strings.TrimSpace(value[len(c.Scheme):])
+		}
+	}
+
+	if c.CookieName != "" {
+		if value, err := ctx.Cookie(c.CookieName); err == nil && value != "" {
+			return value
+		}
+	}
+
+	if c.QueryName != "" {
+		return ctx.Query(c.QueryName)
+	}
+
+	return ""
+}
+
+// Authenticate parses the request token and stores the token and claims on ctx.
+// Claims implementing ITokenClaims must be access tokens.
+func (c *AuthConfig) Authenticate(ctx *gin.Context) error {
+	tokenString := c.GetToken(ctx)
+
+	if tokenString == "" {
+		return ErrTokenMissing
+	}
+
+	claims := c.NewClaims()
+	var token *gjwt.Token
+	var err error
+
+	if tokenClaims, ok := claims.(ITokenClaims); ok {
+		token, err = c.Client.ParseAccessToken(tokenString, tokenClaims)
+	} else {
+		token, err = c.Client.ParseToken(tokenString, claims)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	ctx.Set(ContextTokenKey, token)
+	ctx.Set(ContextClaimsKey, claims)
+	return nil
+}
+
+// Middleware aborts requests without a valid token with 401.
+func (c *AuthConfig) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := c.Authenticate(ctx); err != nil {
+			c.sendUnauthorized(ctx, err)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// Optional lets requests without a token through anonymously, a token that is sent must still be valid.
+func (c *AuthConfig) Optional() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := c.Authenticate(ctx); err != nil && !errors.Is(err, ErrTokenMissing) {
+			c.sendUnauthorized(ctx, err)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// RequireScopes aborts with 403 unless the claims carry all scopes. Use it after Middleware.
+func (c *AuthConfig) RequireScopes(scopes ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, ok := GetClaims[IScopeClaims](ctx)
+
+		if !ok {
+			c.sendUnauthorized(ctx, ErrTokenMissing)
+			return
+		}
+
+		if !containsAll(claims.GetScopes(), scopes) {
+			c.sendForbidden(ctx, "insufficient scope")
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// RequireRoles aborts with 403 unless the claims carry any of roles. Use it after Middleware.
+func (c *AuthConfig) RequireRoles(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, ok := GetClaims[IRoleClaims](ctx)
+
+		if !ok {
+			c.sendUnauthorized(ctx, ErrTokenMissing)
+			return
+		}
+
+		if !containsAny(claims.GetRoles(), roles) {
+			c.sendForbidden(ctx, "insufficient role")
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+func (c *AuthConfig) sendUnauthorized(ctx *gin.Context, err error) {
+	if c.Scheme != "" {
+		ctx.Header("WWW-Authenticate", c.Scheme+` error="invalid_token"`)
+	}
+
+	_ = coder.SendError(ctx, c.Coder, coder.NewError(http.StatusUnauthorized, http.StatusUnauthorized, err.Error()))
+	ctx.Abort()
+}
+
+func (c *AuthConfig) sendForbidden(ctx *gin.Context, message string) {
+	_ = coder.SendError(ctx, c.Coder, coder.NewError(http.StatusForbidden, http.StatusForbidden, message))
+	ctx.Abort()
+}
+
+// NewAuthConfig reads bearer tokens from the Authorization header and answers with the negotiated coder.
+// newClaims must return a new claims pointer per call, e.g. func() jwt.Claims { return &MyClaims{} }.
+func NewAuthConfig(client *JwtClient, newClaims func() gjwt.Claims) *AuthConfig {
+	return &AuthConfig{
+		Client:    client,
+		NewClaims: newClaims,
+		Header:    AuthorizationHeader,
+		Scheme:    SchemeBearer,
+		Coder:     coder.DefaultRegistry,
+	}
+}
+
+// GetClaims returns the claims stored by the middleware as T, T can be the claims pointer type or an interface.
+func GetClaims[T any](ctx *gin.Context) (result T, ok bool) {
+	value, exists := ctx.Get(ContextClaimsKey)
+
+	if !exists {
+		return
+	}
+
+	result, ok = value.(T)
+	return
+}
+
+// GetToken returns the parsed token stored by the middleware.
+func GetToken(ctx *gin.Context) (*gjwt.Token, bool) {
+	value, exists := ctx.Get(ContextTokenKey)
+
+	if !exists {
+		return nil, false
+	}
+
+	token, ok := value.(*gjwt.Token)
+	return token, ok
+}
+
+func containsAll(values, expect []string) bool {
+	for _, e := range expect {
+		if !containsAny(values, []string{e}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsAny(values, expect []string) bool {
+	for _, v := range values {
+		for _, e := range expect {
+			if v == e {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,83 @@
+package jwt
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthConfig_Middleware(t *testing.T) {
+	client, err := NewJwtClient(&Config{PrivateKey: "secret", SignType: SignTypeHS, SignMethod: SignMethodHS256})
+
+	if err != nil {
+		t.Fatalf("new jwt client fail. | err: %s", err)
+	}
+
+	claims := &pairClaims{Name: "dylan"}
+	claims.Subject = "user-1"
+	claims.Scope = "read write"
+	claims.Roles = []string{"editor"}
+	pair, err := client.IssueTokenPair(claims)
+
+	if err != nil {
+		t.Fatalf("issue token pair fail. | err: %s", err)
+	}
+
+	auth := NewAuthConfig(client, func() jwt.Claims { return &pairClaims{} })
+	auth.CookieName = "token"
+	auth.QueryName = "token"
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := func(ctx *gin.Context) {
+		if claims, ok := GetClaims[*pairClaims](ctx); ok {
+			ctx.String(http.StatusOK, claims.Name)
+			return
+		}
+
+		ctx.String(http.StatusOK, "anonymous")
+	}
+	router.GET("/me", auth.Middleware(), handler)
+	router.GET("/public", auth.Optional(), handler)
+	router.GET("/write", auth.Middleware(), auth.RequireScopes("write"), handler)
+	router.GET("/admin", auth.Middleware(), auth.RequireRoles("admin"), handler)
+
+	cases := []struct {
+		path   string
+		header string
+		cookie string
+		status int
+		body   string
+	}{
+		{"/me", "Bearer " + pair.AccessToken, "", http.StatusOK, "dylan"},
+		{"/me", "", pair.AccessToken, http.StatusOK, "dylan"},
+		{"/me?token=" + pair.AccessToken, "", "", http.StatusOK, "dylan"},
+		{"/me", "", "", http.StatusUnauthorized, ""},
+		{"/me", "Bearer " + pair.RefreshToken, "", http.StatusUnauthorized, ""},
+		{"/public", "", "", http.StatusOK, "anonymous"},
+		{"/public", "Bearer invalid", "", http.StatusUnauthorized, ""},
+		{"/write", "Bearer " + pair.AccessToken, "", http.StatusOK, "dylan"},
+		{"/admin", "Bearer " + pair.AccessToken, "", http.StatusForbidden, ""},
+	}
+
+	for i, c := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, c.path, nil)
+
+		if c.header != "" {
+			req.Header.Set(AuthorizationHeader, c.header)
+		}
+
+		if c.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: "token", Value: c.cookie})
+		}
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != c.status || (c.body != "" && w.Body.String() != c.body) {
+			t.Fatalf("case %d unexpected response. | status: %d | body: %s", i, w.Code, w.Body.String())
+		}
+	}
+}
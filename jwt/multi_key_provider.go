@@ -0,0 +1,243 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	gjwt "github.com/golang-jwt/jwt/v4"
+	"sync"
+)
+
+const HeaderKid = "kid"
+
+// IKidKeyProvider is a key provider that signs with one of several keys and stamps its kid in the token header.
+type IKidKeyProvider interface {
+	IKeyProvider
+	GetSigningKey() (kid string, method gjwt.SigningMethod, key any)
+}
+
+type KeyConfig struct {
+	Kid        string `toml:"kid" json:"kid" yaml:"kid"`
+	PrivateKey string `toml:"private_key" json:"private_key" yaml:"private_key"`
+	PublicKey  string `toml:"public_key" json:"public_key" yaml:"public_key"`
+	SignType   string `toml:"sign_type" json:"sign_type" yaml:"sign_type"`
+	SignMethod string `toml:"sign_method" json:"sign_method" yaml:"sign_method"`
+	Retired    bool   `toml:"retired" json:"retired" yaml:"retired"`
+}
+
+type keyEntry struct {
+	provider IKeyProvider
+	retired  bool
+}
+
+// MultiKeyProvider signs with the current key and verifies with any active or retired key picked by kid.
+// Keys can be added, retired and removed at runtime.
+type MultiKeyProvider struct {
+	locker     sync.RWMutex
+	currentKid string
+	keys       map[string]*keyEntry
+}
+
+// AddKey adds or replaces a key. The first active key added becomes the current one.
+func (p *MultiKeyProvider) AddKey(kid string, provider IKeyProvider) {
+	_ = p.addKey(kid, provider, false)
+}
+
+// AddRetiredKey adds or replaces a key that is only used for verification.
+func (p *MultiKeyProvider) AddRetiredKey(kid string, provider IKeyProvider) error {
+	return p.addKey(kid, provider, true)
+}
+
+func (p *MultiKeyProvider) addKey(kid string, provider IKeyProvider, retired bool) error {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	if retired && kid == p.currentKid {
+		return fmt.Errorf("key %s is the current key", kid)
+	}
+
+	p.keys[kid] = &keyEntry{provider: provider, retired: retired}
+
+	if p.currentKid == "" && !retired {
+		p.currentKid = kid
+	}
+
+	return nil
+}
+
+func (p *MultiKeyProvider) AddKeyConfig(conf *KeyConfig) error {
+	provider, err := GetKeyProvider(&Config{
+		PrivateKey: conf.PrivateKey,
+		PublicKey:  conf.PublicKey,
+		SignType:   conf.SignType,
+		SignMethod: conf.SignMethod,
+	})
+
+	if err != nil {
+		return fmt.Errorf("key %s: %w", conf.Kid, err)
+	}
+
+	return p.addKey(conf.Kid, provider, conf.Retired)
+}
+
+// SetCurrent switches signing to kid, the key must exist and not be retired.
+func (p *MultiKeyProvider) SetCurrent(kid string) error {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	entry, ok := p.keys[kid]
+
+	if !ok {
+		return fmt.Errorf("key %s not found", kid)
+	}
+
+	if entry.retired {
+		return fmt.Errorf("key %s is retired", kid)
+	}
+
+	p.currentKid = kid
+	return nil
+}
+
+// RetireKey keeps kid for verification only. The current key can't be retired.
+func (p *MultiKeyProvider) RetireKey(kid string) error {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	entry, ok := p.keys[kid]
+
+	if !ok {
+		return fmt.Errorf("key %s not found", kid)
+	}
+
+	if kid == p.currentKid {
+		return fmt.Errorf("key %s is the current key", kid)
+	}
+
+	entry.retired = true
+	return nil
+}
+
+// RemoveKey drops kid, tokens signed with it no longer verify. The current key can't be removed.
+func (p *MultiKeyProvider) RemoveKey(kid string) error {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	if kid == p.currentKid {
+		return fmt.Errorf("key %s is the current key", kid)
+	}
+
+	delete(p.keys, kid)
+	return nil
+}
+
+func (p *MultiKeyProvider) GetCurrentKid() string {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+
+	return p.currentKid
+}
+
+// GetKids returns the active and retired key ids.
+func (p *MultiKeyProvider) GetKids() (active []string, retired []string) {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+
+	for kid, entry := range p.keys {
+		if entry.retired {
+			retired = append(retired, kid)
+		} else {
+			active = append(active, kid)
+		}
+	}
+
+	return
+}
+
+func (p *MultiKeyProvider) GetProvider(kid string) (provider IKeyProvider, ok bool) {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+
+	entry, ok := p.keys[kid]
+
+	if !ok {
+		return nil, false
+	}
+
+	return entry.provider, true
+}
+
+func (p *MultiKeyProvider) current() (kid string, provider IKeyProvider) {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+
+	if entry, ok := p.keys[p.currentKid]; ok {
+		return p.currentKid, entry.provider
+	}
+
+	return "", nil
+}
+
+func (p *MultiKeyProvider) GetSigningKey() (kid string, method gjwt.SigningMethod, key any) {
+	kid, provider := p.current()
+
+	if provider == nil {
+		return "", nil, nil
+	}
+
+	return kid, provider.GetSigningMethod(), provider.GetPrivateKey()
+}
+
+func (p *MultiKeyProvider) GetSigningMethod() gjwt.SigningMethod {
+	_, method, _ := p.GetSigningKey()
+	return method
+}
+
+func (p *MultiKeyProvider) GetPrivateKey() any {
+	_, _, key := p.GetSigningKey()
+	return key
+}
+
+// GetPublicKey picks the key by the kid header, tokens without kid are checked against the current key.
+func (p *MultiKeyProvider) GetPublicKey(token *gjwt.Token) (interface{}, error) {
+	kid, _ := token.Header[HeaderKid].(string)
+
+	var provider IKeyProvider
+
+	if kid == "" {
+		_, provider = p.current()
+	} else {
+		provider, _ = p.GetProvider(kid)
+	}
+
+	if provider == nil {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	if method := provider.GetSigningMethod(); method == nil || method.Alg() != token.Method.Alg() {
+		return nil, errors.New("signing method not match key")
+	}
+
+	return provider.GetPublicKey(token)
+}
+
+func NewMultiKeyProvider() *MultiKeyProvider {
+	return &MultiKeyProvider{keys: make(map[string]*keyEntry)}
+}
+
+func newMultiKeyProvider(conf *Config) (result *MultiKeyProvider, err error) {
+	result = NewMultiKeyProvider()
+
+	for _, keyConf := range conf.Keys {
+		if err = result.AddKeyConfig(keyConf); err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.Kid != "" {
+		if err = result.SetCurrent(conf.Kid); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
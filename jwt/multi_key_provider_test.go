@@ -0,0 +1,124 @@
+package jwt
+
+import (
+	"github.com/golang-jwt/jwt/v4"
+	"testing"
+	"time"
+)
+
+func newRotationClaims() *jwt.RegisteredClaims {
+	return &jwt.RegisteredClaims{
+		Subject:   "user-1",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+}
+
+func TestMultiKeyProvider_Rotate(t *testing.T) {
+	client, err := NewJwtClient(&Config{
+		Kid: "k1",
+		Keys: []*KeyConfig{
+			{Kid: "k1", PrivateKey: "secret-1", SignType: SignTypeHS, SignMethod: SignMethodHS256},
+			{Kid: "k0", PrivateKey: "secret-0", SignType: SignTypeHS, SignMethod: SignMethodHS256, Retired: true},
+			{Kid: "es", PrivateKey: conf.PrivateKey, PublicKey: conf.PublicKey, SignType: SignTypeES, SignMethod: SignMethodES256},
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("new jwt client fail. | err: %s", err)
+	}
+
+	provider := client.GetKeyProvider().(*MultiKeyProvider)
+	oldToken, err := client.GenerateToken(newRotationClaims())
+
+	if err != nil {
+		t.Fatalf("generate token fail. | err: %s", err)
+	}
+
+	token, err := client.ParseToken(oldToken, &jwt.RegisteredClaims{})
+
+	if err != nil || token.Header[HeaderKid] != "k1" {
+		t.Fatalf("parse token fail. | header: %v | err: %v", token.Header, err)
+	}
+
+	if err = provider.SetCurrent("k0"); err == nil {
+		t.Fatalf("retired key must not become current")
+	}
+
+	// rotate to es, k1 stays valid for outstanding tokens
+	if err = provider.SetCurrent("es"); err != nil {
+		t.Fatalf("set current fail. | err: %s", err)
+	}
+
+	if err = provider.RetireKey("k1"); err != nil {
+		t.Fatalf("retire key fail. | err: %s", err)
+	}
+
+	newToken, err := client.GenerateToken(newRotationClaims())
+
+	if err != nil {
+		t.Fatalf("generate token fail. | err: %s", err)
+	}
+
+	for _, s := range []string{oldToken, newToken} {
+		if _, err = client.ParseToken(s, &jwt.RegisteredClaims{}); err != nil {
+			t.Fatalf("parse token after rotation fail. | err: %s", err)
+		}
+	}
+
+	if err = provider.RemoveKey("k1"); err != nil {
+		t.Fatalf("remove key fail. | err: %s", err)
+	}
+
+	if _, err = client.ParseToken(oldToken, &jwt.RegisteredClaims{}); err == nil {
+		t.Fatalf("token of a removed key must not verify")
+	}
+
+	// a token claiming kid es with another algorithm is rejected
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, newRotationClaims())
+	forged.Header[HeaderKid] = "es"
+	forgedString, _ := forged.SignedString([]byte("secret-1"))
+
+	if _, err = client.ParseToken(forgedString, &jwt.RegisteredClaims{}); err == nil {
+		t.Fatalf("token with mismatched algorithm must not verify")
+	}
+}
+
+func TestMultiKeyProvider_RetiredFirst(t *testing.T) {
+	old, err := NewJwtClient(&Config{Kid: "old", PrivateKey: "secret-old", SignType: SignTypeHS, SignMethod: SignMethodHS256})
+
+	if err != nil {
+		t.Fatalf("new jwt client fail. | err: %s", err)
+	}
+
+	oldToken, _ := old.GenerateToken(newRotationClaims())
+
+	client, err := NewJwtClient(&Config{
+		Kid: "new",
+		Keys: []*KeyConfig{
+			{Kid: "old", PrivateKey: "secret-old", SignType: SignTypeHS, SignMethod: SignMethodHS256, Retired: true},
+			{Kid: "new", PrivateKey: "secret-new", SignType: SignTypeHS, SignMethod: SignMethodHS256},
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("new jwt client with retired key first fail. | err: %s", err)
+	}
+
+	if kid := client.GetKeyProvider().(*MultiKeyProvider).GetCurrentKid(); kid != "new" {
+		t.Fatalf("unexpected current kid. | kid: %s", kid)
+	}
+
+	if _, err = client.ParseToken(oldToken, &jwt.RegisteredClaims{}); err != nil {
+		t.Fatalf("parse token of retired key fail. | err: %s", err)
+	}
+
+	// without Kid the first active key is current, never a retired one
+	provider, err := newMultiKeyProvider(&Config{Keys: []*KeyConfig{
+		{Kid: "old", PrivateKey: "secret-old", SignType: SignTypeHS, SignMethod: SignMethodHS256, Retired: true},
+		{Kid: "new", PrivateKey: "secret-new", SignType: SignTypeHS, SignMethod: SignMethodHS256},
+	}})
+
+	if err != nil || provider.GetCurrentKid() != "new" {
+		t.Fatalf("unexpected current key. | err: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package jwt
+
+import (
+	"crypto/rsa"
+	gjwt "github.com/golang-jwt/jwt/v4"
+)
+
+/*
+PS256 = RSASSA-PSS 使用 SHA-256 和 MGF1 with SHA-256
+
+与 RS256 使用同样的 RSA 密钥，签名带随机盐，同一内容每次签名结果不同。
+*/
+
+const (
+	SignMethodPS256 = "PS256"
+	SignMethodPS384 = "PS384"
+	SignMethodPS512 = "PS512"
+)
+
+var psSignMethod = map[string]gjwt.SigningMethod{
+	SignMethodPS256: gjwt.SigningMethodPS256,
+	SignMethodPS384: gjwt.SigningMethodPS384,
+	SignMethodPS512: gjwt.SigningMethodPS512,
+}
+
+type PsTokenProducer struct {
+	privateKey *rsa.PrivateKey
+	publicKey  *rsa.PublicKey
+	signMethod gjwt.SigningMethod
+}
+
+func (p *PsTokenProducer) GetSigningMethod() gjwt.SigningMethod {
+	return p.signMethod
+}
+
+func (p *PsTokenProducer) GetPrivateKey() any {
+	return p.privateKey
+}
+
+func (p *PsTokenProducer) GetPublicKey(token *gjwt.Token) (interface{}, error) {
+	return p.publicKey, nil
+}
+
+func (p *PsTokenProducer) GetPublicKeys() []*PublicKey {
+	return []*PublicKey{{Alg: p.signMethod.Alg(), Key: p.publicKey}}
+}
+
+func newPsTokenProducer(privateKeyString, publicKeyString, signMethod string) (result *PsTokenProducer, err error) {
+	privateKey, err := gjwt.ParseRSAPrivateKeyFromPEM([]byte(privateKeyString))
+	if err != nil {
+		return
+	}
+
+	publicKey, err := gjwt.ParseRSAPublicKeyFromPEM([]byte(publicKeyString))
+	if err != nil {
+		return
+	}
+
+	result = &PsTokenProducer{
+		privateKey: privateKey,
+		publicKey:  publicKey,
+		signMethod: gjwt.SigningMethodPS256,
+	}
+
+	if signMethod, ok := psSignMethod[signMethod]; ok {
+		result.signMethod = signMethod
+	}
+
+	return
+}
@@ -0,0 +1,253 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	oRedis "github.com/dylanpeng/golib/redis"
+	gjwt "github.com/golang-jwt/jwt/v4"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// IRevocationStore keeps revoked token ids and per subject revocation times until they expire.
+// Revocation times have second precision, tokens issued in the same second as RevokeSubject stay valid.
+type IRevocationStore interface {
+	RevokeToken(jti string, expiresAt time.Time) error
+	RevokeSubject(subject string, before time.Time, ttl time.Duration) error
+	IsRevoked(jti, subject string, issuedAt time.Time) (bool, error)
+}
+
+// MemoryRevocationStore keeps revocations in process memory, expired entries are purged while new ones are added.
+type MemoryRevocationStore struct {
+	locker   sync.RWMutex
+	tokens   map[string]time.Time
+	subjects map[string]*subjectRevocation
+	purged   time.Time
+}
+
+type subjectRevocation struct {
+	before   int64
+	expireAt time.Time
+}
+
+func (s *MemoryRevocationStore) RevokeToken(jti string, expiresAt time.Time) error {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	s.purge()
+	s.tokens[jti] = expiresAt
+	return nil
+}
+
+func (s *MemoryRevocationStore) RevokeSubject(subject string, before time.Time, ttl time.Duration) error {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	s.purge()
+	s.subjects[subject] = &subjectRevocation{before: before.Unix(), expireAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryRevocationStore) IsRevoked(jti, subject string, issuedAt time.Time) (bool, error) {
+	s.locker.RLock()
+	defer s.locker.RUnlock()
+
+	now := time.Now()
+
+	if expire, ok := s.tokens[jti]; ok && jti != "" && now.Before(expire) {
+		return true, nil
+	}
+
+	if r, ok := s.subjects[subject]; ok && subject != "" && now.Before(r.expireAt) {
+		return issuedAt.Unix() < r.before, nil
+	}
+
+	return false, nil
+}
+
+func (s *MemoryRevocationStore) purge() {
+	now := time.Now()
+
+	if now.Sub(s.purged) < time.Minute {
+		return
+	}
+
+	for k, expire := range s.tokens {
+		if now.After(expire) {
+			delete(s.tokens, k)
+		}
+	}
+
+	for k, r := range s.subjects {
+		if now.After(r.expireAt) {
+			delete(s.subjects, k)
+		}
+	}
+
+	s.purged = now
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		tokens:   make(map[string]time.Time),
+		subjects: make(map[string]*subjectRevocation),
+	}
+}
+
+// RedisRevocationStore keeps revocations in redis so every instance sees them, keys expire with the tokens.
+type RedisRevocationStore struct {
+	pool   *oRedis.Pool
+	name   string
+	Prefix string
+}
+
+func (s *RedisRevocationStore) RevokeToken(jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+
+	if ttl <= 0 {
+		return nil
+	}
+
+	client, err := s.pool.Get(s.name)
+
+	if err != nil {
+		return err
+	}
+
+	return client.Set(context.Background(), s.Prefix+"jti:"+jti, 1, ttl).Err()
+}
+
+func (s *RedisRevocationStore) RevokeSubject(subject string, before time.Time, ttl time.Duration) error {
+	client, err := s.pool.Get(s.name)
+
+	if err != nil {
+		return err
+	}
+
+	return client.Set(context.Background(), s.Prefix+"sub:"+subject, before.Unix(), ttl).Err()
+}
+
+func (s *RedisRevocationStore) IsRevoked(jti, subject string, issuedAt time.Time) (bool, error) {
+	client, err := s.pool.Get(s.name)
+
+	if err != nil {
+		return false, err
+	}
+
+	values, err := client.MGet(context.Background(), s.Prefix+"jti:"+jti, s.Prefix+"sub:"+subject).Result()
+
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+
+	if jti != "" && len(values) > 0 && values[0] != nil {
+		return true, nil
+	}
+
+	if subject != "" && len(values) > 1 && values[1] != nil {
+		before, err := strconv.ParseInt(fmt.Sprint(values[1]), 10, 64)
+
+		if err != nil {
+			return false, err
+		}
+
+		return issuedAt.Unix() < before, nil
+	}
+
+	return false, nil
+}
+
+// NewRedisRevocationStore stores revocations with the named client of pool.
+func NewRedisRevocationStore(pool *oRedis.Pool, name string) *RedisRevocationStore {
+	return &RedisRevocationStore{pool: pool, name: name, Prefix: "jwt:revoked:"}
+}
+
+// SetRevocationStore enables revocation checks in ParseToken.
+func (c *JwtClient) SetRevocationStore(store IRevocationStore) {
+	c.revocationStore = store
+}
+
+// RevokeToken revokes a token until its own expiry, the token must carry a jti.
+func (c *JwtClient) RevokeToken(tokenString string) error {
+	claims := gjwt.MapClaims{}
+	tokenString, err := c.decrypt(tokenString)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = gjwt.ParseWithClaims(tokenString, claims, c.keyFunc); err != nil {
+		return err
+	}
+
+	jti, _ := claims["jti"].(string)
+
+	if jti == "" {
+		return errors.New("token has no jti")
+	}
+
+	expiresAt := time.Now().Add(c.getMaxExpireTime())
+
+	if exp, ok := claims["exp"].(float64); ok {
+		expiresAt = time.Unix(int64(exp), 0)
+	}
+
+	return c.RevokeJti(jti, expiresAt)
+}
+
+func (c *JwtClient) RevokeJti(jti string, expiresAt time.Time) error {
+	if c.revocationStore == nil {
+		return errors.New("no revocation store")
+	}
+
+	return c.revocationStore.RevokeToken(jti, expiresAt)
+}
+
+// RevokeSubject revokes every token of subject issued before the given time, e.g. on logout everywhere
+// or password change. The entry lives as long as the longest token lifetime.
+func (c *JwtClient) RevokeSubject(subject string, before time.Time) error {
+	if c.revocationStore == nil {
+		return errors.New("no revocation store")
+	}
+
+	return c.revocationStore.RevokeSubject(subject, before, c.getMaxExpireTime())
+}
+
+func (c *JwtClient) checkRevoked(claims gjwt.MapClaims) error {
+	if c.revocationStore == nil {
+		return nil
+	}
+
+	jti, _ := claims["jti"].(string)
+	subject, _ := claims["sub"].(string)
+	var issuedAt time.Time
+
+	if iat, ok := claims["iat"].(float64); ok {
+		issuedAt = time.Unix(int64(iat), 0)
+	}
+
+	revoked, err := c.revocationStore.IsRevoked(jti, subject, issuedAt)
+
+	if err != nil {
+		return err
+	}
+
+	if revoked {
+		return ErrTokenRevoked
+	}
+
+	return nil
+}
+
+func (c *JwtClient) getMaxExpireTime() time.Duration {
+	if expire, refreshExpire := c.getExpireTime(), c.getRefreshExpireTime(); expire > refreshExpire {
+		return expire
+	}
+
+	return c.getRefreshExpireTime()
+}
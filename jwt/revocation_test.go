@@ -0,0 +1,54 @@
+package jwt
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestJwtClient_Revoke(t *testing.T) {
+	client, err := NewJwtClient(&Config{PrivateKey: "secret", SignType: SignTypeHS, SignMethod: SignMethodHS256})
+
+	if err != nil {
+		t.Fatalf("new jwt client fail. | err: %s", err)
+	}
+
+	client.SetRevocationStore(NewMemoryRevocationStore())
+	claims := &pairClaims{}
+	claims.Subject = "user-1"
+	pair, err := client.IssueTokenPair(claims)
+
+	if err != nil {
+		t.Fatalf("issue token pair fail. | err: %s", err)
+	}
+
+	if err = client.RevokeToken(pair.AccessToken); err != nil {
+		t.Fatalf("revoke token fail. | err: %s", err)
+	}
+
+	if _, err = client.ParseToken(pair.AccessToken, &pairClaims{}); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("revoked token accepted. | err: %v", err)
+	}
+
+	if _, err = client.ParseToken(pair.RefreshToken, &pairClaims{}); err != nil {
+		t.Fatalf("parse refresh token fail. | err: %s", err)
+	}
+
+	if err = client.RevokeSubject("user-1", time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("revoke subject fail. | err: %s", err)
+	}
+
+	if _, err = client.Refresh(pair.RefreshToken, &pairClaims{}); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("token of revoked subject accepted. | err: %v", err)
+	}
+}
+
+func TestMemoryRevocationStore_Expire(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	_ = store.RevokeToken("expired", time.Now().Add(-time.Second))
+	_ = store.RevokeSubject("user-1", time.Now(), -time.Second)
+
+	if revoked, _ := store.IsRevoked("expired", "user-1", time.Time{}); revoked {
+		t.Fatalf("expired revocation still applies")
+	}
+}
@@ -0,0 +1,78 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/golang-jwt/jwt/v4"
+	"testing"
+)
+
+func newEdConfig(t *testing.T) *Config {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+
+	if err != nil {
+		t.Fatalf("generate ed25519 key fail. | err: %s", err)
+	}
+
+	privateBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+
+	if err != nil {
+		t.Fatalf("marshal private key fail. | err: %s", err)
+	}
+
+	publicBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+
+	if err != nil {
+		t.Fatalf("marshal public key fail. | err: %s", err)
+	}
+
+	return &Config{
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateBytes})),
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicBytes})),
+		SignType:   SignTypeEd,
+	}
+}
+
+func TestSignTypes(t *testing.T) {
+	rsa := newRsaKeyConfig(t, "")
+	cases := []struct {
+		conf *Config
+		alg  string
+	}{
+		{newEdConfig(t), SignMethodEdDSA},
+		{&Config{PrivateKey: rsa.PrivateKey, PublicKey: rsa.PublicKey, SignType: SignTypePS}, SignMethodPS256},
+		{&Config{PrivateKey: rsa.PrivateKey, PublicKey: rsa.PublicKey, SignType: SignTypePS, SignMethod: SignMethodPS512}, SignMethodPS512},
+	}
+
+	for i, c := range cases {
+		client, err := NewJwtClient(c.conf)
+
+		if err != nil {
+			t.Fatalf("case %d new jwt client fail. | err: %s", i, err)
+		}
+
+		tokenString, err := client.GenerateToken(newRotationClaims())
+
+		if err != nil {
+			t.Fatalf("case %d generate token fail. | err: %s", i, err)
+		}
+
+		token, err := client.ParseToken(tokenString, &jwt.RegisteredClaims{})
+
+		if err != nil || token.Method.Alg() != c.alg {
+			t.Fatalf("case %d parse token fail. | alg: %s | err: %v", i, token.Method.Alg(), err)
+		}
+
+		jwks, _ := ExportJwks(client.GetKeyProvider())
+
+		if len(jwks.Keys) != 1 || jwks.Keys[0].Alg != c.alg {
+			t.Fatalf("case %d export jwks fail. | keys: %v", i, jwks.Keys)
+		}
+
+		if _, err = jwks.Keys[0].PublicKey(); err != nil {
+			t.Fatalf("case %d decode jwk fail. | err: %s", i, err)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/dylanpeng/golib/coder"
+	gjwt "github.com/golang-jwt/jwt/v4"
+	"strings"
+	"time"
+)
+
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+const (
+	DefaultExpireTime        = 2 * 60 * 60
+	DefaultRefreshExpireTime = 7 * 24 * 60 * 60
+)
+
+var (
+	ErrNotRefreshToken     = errors.New("token is not a refresh token")
+	ErrNotAccessToken      = errors.New("token is not an access token")
+	ErrRefreshTokenReused  = errors.New("refresh token already used")
+	ErrRefreshTokenMissJti = errors.New("refresh token has no jti")
+)
+
+// TokenClaims is the claims set of issued token pairs, embed it to carry custom claims.
+// Scope is space separated as in RFC 8693.
+type TokenClaims struct {
+	gjwt.RegisteredClaims
+	TokenType string   `json:"token_type,omitempty"`
+	Scope     string   `json:"scope,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+}
+
+func (c *TokenClaims) GetTokenClaims() *TokenClaims {
+	return c
+}
+
+func (c *TokenClaims) GetScopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+func (c *TokenClaims) GetRoles() []string {
+	return c.Roles
+}
+
+type ITokenClaims interface {
+	gjwt.Claims
+	GetTokenClaims() *TokenClaims
+}
+
+type TokenPair struct {
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// IssueTokenPair signs an access and a refresh token from claims. The registered time claims and jti
+// are filled from config, ExpireTime and RefreshExpireTime are in seconds.
+// claims is modified in place and holds the refresh token claims on return.
+func (c *JwtClient) IssueTokenPair(claims ITokenClaims) (pair *TokenPair, err error) {
+	now := time.Now()
+	pair = &TokenPair{
+		AccessExpiresAt:  now.Add(c.getExpireTime()),
+		RefreshExpiresAt: now.Add(c.getRefreshExpireTime()),
+	}
+
+	if pair.AccessToken, err = c.issueToken(claims, TokenTypeAccess, now, pair.AccessExpiresAt); err != nil {
+		return nil, err
+	}
+
+	if pair.RefreshToken, err = c.issueToken(claims, TokenTypeRefresh, now, pair.RefreshExpiresAt); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// Refresh exchanges a refresh token for a new pair. claims receives the parsed refresh token
+// and is reissued, so custom claims carry over. Each refresh token can be used once.
+func (c *JwtClient) Refresh(refreshToken string, claims ITokenClaims) (*TokenPair, error) {
+	if _, err := c.ParseToken(refreshToken, claims); err != nil {
+		return nil, err
+	}
+
+	base := claims.GetTokenClaims()
+
+	if base.TokenType != TokenTypeRefresh {
+		return nil, ErrNotRefreshToken
+	}
+
+	if base.ID == "" {
+		return nil, ErrRefreshTokenMissJti
+	}
+
+	ttl := time.Minute
+
+	if base.ExpiresAt != nil {
+		// the token still parses within the leeway after exp, it must stay marked as used as long
+		ttl = time.Until(base.ExpiresAt.Time)
+
+		if validation := c.getValidation(); validation != nil {
+			ttl += time.Duration(validation.Leeway) * time.Second
+		}
+
+		if ttl < time.Second {
+			ttl = time.Second
+		}
+	}
+
+	ok, err := c.refreshStore.Use(base.ID, ttl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, ErrRefreshTokenReused
+	}
+
+	return c.IssueTokenPair(claims)
+}
+
+// ParseAccessToken parses a token and rejects refresh tokens presented as access tokens.
+func (c *JwtClient) ParseAccessToken(tokenString string, claims ITokenClaims) (*gjwt.Token, error) {
+	token, err := c.ParseToken(tokenString, claims)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.GetTokenClaims().TokenType != TokenTypeAccess {
+		return nil, ErrNotAccessToken
+	}
+
+	return token, nil
+}
+
+// SetRefreshStore replaces the store of used refresh token ids, share one across instances to
+// make rotation hold cluster wide.
+func (c *JwtClient) SetRefreshStore(store coder.INonceStore) {
+	c.refreshStore = store
+}
+
+func (c *JwtClient) issueToken(claims ITokenClaims, tokenType string, now, expiresAt time.Time) (string, error) {
+	id, err := newTokenId()
+
+	if err != nil {
+		return "", err
+	}
+
+	base := claims.GetTokenClaims()
+	base.TokenType = tokenType
+	base.ID = id
+	base.IssuedAt = gjwt.NewNumericDate(now)
+	base.NotBefore = gjwt.NewNumericDate(now)
+	base.ExpiresAt = gjwt.NewNumericDate(expiresAt)
+
+	return c.GenerateToken(claims)
+}
+
+func (c *JwtClient) getExpireTime() time.Duration {
+	if c.conf == nil || c.conf.ExpireTime <= 0 {
+		return DefaultExpireTime * time.Second
+	}
+
+	return time.Duration(c.conf.ExpireTime) * time.Second
+}
+
+func (c *JwtClient) getRefreshExpireTime() time.Duration {
+	if c.conf == nil || c.conf.RefreshExpireTime <= 0 {
+		return DefaultRefreshExpireTime * time.Second
+	}
+
+	return time.Duration(c.conf.RefreshExpireTime) * time.Second
+}
+
+func newTokenId() (string, error) {
+	data := make([]byte, 16)
+
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(data), nil
+}
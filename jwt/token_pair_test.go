@@ -0,0 +1,97 @@
+package jwt
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v4"
+	"testing"
+	"time"
+)
+
+type pairClaims struct {
+	TokenClaims
+	Name string `json:"name"`
+}
+
+func TestJwtClient_IssueTokenPair(t *testing.T) {
+	client, err := NewJwtClient(&Config{PrivateKey: "secret", SignType: SignTypeHS, SignMethod: SignMethodHS256, ExpireTime: 60, RefreshExpireTime: 3600})
+
+	if err != nil {
+		t.Fatalf("new jwt client fail. | err: %s", err)
+	}
+
+	claims := &pairClaims{Name: "dylan"}
+	claims.Subject = "user-1"
+	pair, err := client.IssueTokenPair(claims)
+
+	if err != nil {
+		t.Fatalf("issue token pair fail. | err: %s", err)
+	}
+
+	if d := time.Until(pair.AccessExpiresAt); d <= 0 || d > time.Minute {
+		t.Fatalf("unexpected access expire. | expire: %s", pair.AccessExpiresAt)
+	}
+
+	access := &pairClaims{}
+
+	if _, err = client.ParseAccessToken(pair.AccessToken, access); err != nil {
+		t.Fatalf("parse access token fail. | err: %s", err)
+	}
+
+	if access.Subject != "user-1" || access.Name != "dylan" || access.ID == "" || access.IssuedAt == nil || access.NotBefore == nil {
+		t.Fatalf("unexpected access claims. | claims: %+v", access)
+	}
+
+	if _, err = client.ParseAccessToken(pair.RefreshToken, &pairClaims{}); !errors.Is(err, ErrNotAccessToken) {
+		t.Fatalf("refresh token accepted as access token. | err: %v", err)
+	}
+
+	if _, err = client.Refresh(pair.AccessToken, &pairClaims{}); !errors.Is(err, ErrNotRefreshToken) {
+		t.Fatalf("access token accepted as refresh token. | err: %v", err)
+	}
+
+	refreshed := &pairClaims{}
+	next, err := client.Refresh(pair.RefreshToken, refreshed)
+
+	if err != nil {
+		t.Fatalf("refresh fail. | err: %s", err)
+	}
+
+	if next.RefreshToken == pair.RefreshToken || refreshed.Name != "dylan" {
+		t.Fatalf("refresh token not rotated. | claims: %+v", refreshed)
+	}
+
+	if _, err = client.Refresh(pair.RefreshToken, &pairClaims{}); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("used refresh token accepted. | err: %v", err)
+	}
+
+	if _, err = client.Refresh(next.RefreshToken, &pairClaims{}); err != nil {
+		t.Fatalf("refresh rotated token fail. | err: %s", err)
+	}
+}
+
+func TestJwtClient_RefreshLeeway(t *testing.T) {
+	client, err := NewJwtClient(&Config{PrivateKey: "secret", SignType: SignTypeHS, SignMethod: SignMethodHS256, Validation: &ValidationConfig{Leeway: 30}})
+
+	if err != nil {
+		t.Fatalf("new jwt client fail. | err: %s", err)
+	}
+
+	// expired, but still within the leeway
+	claims := &pairClaims{}
+	claims.ID = "refresh-1"
+	claims.TokenType = TokenTypeRefresh
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-5 * time.Second))
+	refreshToken, err := client.GenerateToken(claims)
+
+	if err != nil {
+		t.Fatalf("generate token fail. | err: %s", err)
+	}
+
+	if _, err = client.Refresh(refreshToken, &pairClaims{}); err != nil {
+		t.Fatalf("refresh within leeway fail. | err: %s", err)
+	}
+
+	if _, err = client.Refresh(refreshToken, &pairClaims{}); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("used refresh token accepted within leeway. | err: %v", err)
+	}
+}
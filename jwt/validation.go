@@ -0,0 +1,179 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	gjwt "github.com/golang-jwt/jwt/v4"
+	"time"
+)
+
+// Errors returned by ParseToken, compare with errors.Is.
+var (
+	ErrTokenMalformed        = gjwt.ErrTokenMalformed
+	ErrTokenUnverifiable     = gjwt.ErrTokenUnverifiable
+	ErrTokenSignatureInvalid = gjwt.ErrTokenSignatureInvalid
+	ErrTokenExpired          = gjwt.ErrTokenExpired
+	ErrTokenNotValidYet      = gjwt.ErrTokenNotValidYet
+	ErrTokenUsedBeforeIssued = gjwt.ErrTokenUsedBeforeIssued
+	ErrTokenInvalidIssuer    = gjwt.ErrTokenInvalidIssuer
+	ErrTokenInvalidAudience  = gjwt.ErrTokenInvalidAudience
+	ErrTokenMethodNotAllowed = errors.New("token signing method not allowed")
+	ErrTokenMissingClaim     = errors.New("token is missing required claim")
+)
+
+// ValidationConfig makes ParseToken check the registered time claims itself with Leeway, claims.Valid still runs
+// for the caller's own checks. Audience passes when the token carries any of the listed values, Leeway is in seconds.
+type ValidationConfig struct {
+	ValidMethods   []string `toml:"valid_methods" json:"valid_methods" yaml:"valid_methods"`
+	Issuer         string   `toml:"issuer" json:"issuer" yaml:"issuer"`
+	Audience       []string `toml:"audience" json:"audience" yaml:"audience"`
+	Leeway         int      `toml:"leeway" json:"leeway" yaml:"leeway"`
+	RequiredClaims []string `toml:"required_claims" json:"required_claims" yaml:"required_claims"`
+}
+
+// IValidMethodsProvider is implemented by providers that verify more algorithms than they sign with.
+type IValidMethodsProvider interface {
+	GetValidMethods() []string
+}
+
+// GetValidMethods lists the algorithms of all active and retired keys.
+func (p *MultiKeyProvider) GetValidMethods() []string {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+
+	result := make([]string, 0, len(p.keys))
+
+	for _, entry := range p.keys {
+		result = append(result, getValidMethods(entry.provider)...)
+	}
+
+	return result
+}
+
+// getValidMethods returns the algorithms a provider accepts, nil means the provider checks the algorithm itself.
+func getValidMethods(provider IKeyProvider) []string {
+	if p, ok := provider.(IValidMethodsProvider); ok {
+		return p.GetValidMethods()
+	}
+
+	if method := provider.GetSigningMethod(); method != nil {
+		return []string{method.Alg()}
+	}
+
+	return nil
+}
+
+func hasMethod(methods []string, alg string) bool {
+	for _, m := range methods {
+		if m == alg {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *JwtClient) getValidation() *ValidationConfig {
+	if c.conf == nil {
+		return nil
+	}
+
+	return c.conf.Validation
+}
+
+// keyFunc rejects algorithms outside the whitelist before a key is looked up, so a token can never be
+// verified with a key of another family. The whitelist is the configured one or the provider's own methods.
+func (c *JwtClient) keyFunc(token *gjwt.Token) (interface{}, error) {
+	var methods []string
+
+	if validation := c.getValidation(); validation != nil && len(validation.ValidMethods) > 0 {
+		methods = validation.ValidMethods
+	} else {
+		methods = getValidMethods(c.keyProvider)
+	}
+
+	if methods != nil && !hasMethod(methods, token.Method.Alg()) {
+		return nil, fmt.Errorf("%w: %s", ErrTokenMethodNotAllowed, token.Method.Alg())
+	}
+
+	return c.keyProvider.GetPublicKey(token)
+}
+
+func (c *JwtClient) parserOptions() []gjwt.ParserOption {
+	if c.getValidation() != nil {
+		return []gjwt.ParserOption{gjwt.WithoutClaimsValidation()}
+	}
+
+	return nil
+}
+
+// timeValidationErrors are the claims.Valid failures validateClaims checks again with the leeway.
+const timeValidationErrors = gjwt.ValidationErrorExpired | gjwt.ValidationErrorNotValidYet | gjwt.ValidationErrorIssuedAt
+
+// validateCustomClaims runs claims.Valid, which the parser skips when validation is configured.
+// Its time errors are ignored, the leeway aware checks of validateClaims replace them.
+func validateCustomClaims(claims gjwt.Claims) error {
+	err := claims.Valid()
+
+	if err == nil {
+		return nil
+	}
+
+	var validationError *gjwt.ValidationError
+
+	if errors.As(err, &validationError) && validationError.Errors != 0 && validationError.Errors&^timeValidationErrors == 0 {
+		return nil
+	}
+
+	return err
+}
+
+func (c *JwtClient) validateClaims(claims gjwt.MapClaims) error {
+	validation := c.getValidation()
+
+	if validation == nil {
+		return nil
+	}
+
+	for _, name := range validation.RequiredClaims {
+		if _, ok := claims[name]; !ok {
+			return fmt.Errorf("%w: %s", ErrTokenMissingClaim, name)
+		}
+	}
+
+	now := time.Now().Unix()
+	leeway := int64(validation.Leeway)
+
+	if !claims.VerifyExpiresAt(now-leeway, false) {
+		return ErrTokenExpired
+	}
+
+	if !claims.VerifyNotBefore(now+leeway, false) {
+		return ErrTokenNotValidYet
+	}
+
+	if !claims.VerifyIssuedAt(now+leeway, false) {
+		return ErrTokenUsedBeforeIssued
+	}
+
+	if validation.Issuer != "" && !claims.VerifyIssuer(validation.Issuer, true) {
+		return fmt.Errorf("%w: %v", ErrTokenInvalidIssuer, claims["iss"])
+	}
+
+	if len(validation.Audience) > 0 {
+		matched := false
+
+		for _, aud := range validation.Audience {
+			if claims.VerifyAudience(aud, true) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return fmt.Errorf("%w: %v", ErrTokenInvalidAudience, claims["aud"])
+		}
+	}
+
+	return nil
+}
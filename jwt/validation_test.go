@@ -0,0 +1,95 @@
+package jwt
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v4"
+	"testing"
+	"time"
+)
+
+var errMissingTenant = errors.New("missing tenant")
+
+type tenantClaims struct {
+	jwt.RegisteredClaims
+	Tenant string `json:"tenant"`
+}
+
+func (c *tenantClaims) Valid() error {
+	if c.Tenant == "" {
+		return errMissingTenant
+	}
+
+	return c.RegisteredClaims.Valid()
+}
+
+func TestJwtClient_Validation(t *testing.T) {
+	validation := &ValidationConfig{Issuer: "golib", Audience: []string{"api"}, Leeway: 30, RequiredClaims: []string{"sub"}}
+	client, err := NewJwtClient(&Config{PrivateKey: "secret", SignType: SignTypeHS, SignMethod: SignMethodHS256, Validation: validation})
+
+	if err != nil {
+		t.Fatalf("new jwt client fail. | err: %s", err)
+	}
+
+	now := time.Now()
+	cases := []struct {
+		claims *jwt.RegisteredClaims
+		expect error
+	}{
+		{&jwt.RegisteredClaims{Issuer: "golib", Audience: []string{"api"}, Subject: "u", ExpiresAt: jwt.NewNumericDate(now.Add(-10 * time.Second))}, nil},
+		{&jwt.RegisteredClaims{Issuer: "golib", Audience: []string{"api"}, Subject: "u", ExpiresAt: jwt.NewNumericDate(now.Add(-time.Minute))}, ErrTokenExpired},
+		{&jwt.RegisteredClaims{Issuer: "golib", Audience: []string{"api"}, Subject: "u", NotBefore: jwt.NewNumericDate(now.Add(time.Minute))}, ErrTokenNotValidYet},
+		{&jwt.RegisteredClaims{Issuer: "other", Audience: []string{"api"}, Subject: "u"}, ErrTokenInvalidIssuer},
+		{&jwt.RegisteredClaims{Issuer: "golib", Audience: []string{"web"}, Subject: "u"}, ErrTokenInvalidAudience},
+		{&jwt.RegisteredClaims{Issuer: "golib", Audience: []string{"api"}}, ErrTokenMissingClaim},
+	}
+
+	for i, c := range cases {
+		tokenString, err := client.GenerateToken(c.claims)
+
+		if err != nil {
+			t.Fatalf("case %d generate token fail. | err: %s", i, err)
+		}
+
+		if _, err = client.ParseToken(tokenString, &jwt.RegisteredClaims{}); !errors.Is(err, c.expect) {
+			t.Fatalf("case %d unexpected error. | expect: %v | err: %v", i, c.expect, err)
+		}
+	}
+
+	// the claims' own checks still run, their time checks are replaced by the leeway aware ones
+	registered := jwt.RegisteredClaims{Issuer: "golib", Audience: []string{"api"}, Subject: "u", ExpiresAt: jwt.NewNumericDate(now.Add(-10 * time.Second))}
+
+	for i, c := range []struct {
+		tenant string
+		expect error
+	}{{"t", nil}, {"", errMissingTenant}} {
+		tokenString, _ := client.GenerateToken(&tenantClaims{RegisteredClaims: registered, Tenant: c.tenant})
+
+		if _, err = client.ParseToken(tokenString, &tenantClaims{}); !errors.Is(err, c.expect) {
+			t.Fatalf("custom case %d unexpected error. | expect: %v | err: %v", i, c.expect, err)
+		}
+	}
+}
+
+func TestJwtClient_ValidMethods(t *testing.T) {
+	client, err := NewJwtClient(&Config{PrivateKey: "secret", SignType: SignTypeHS, SignMethod: SignMethodHS256})
+
+	if err != nil {
+		t.Fatalf("new jwt client fail. | err: %s", err)
+	}
+
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS512, newRotationClaims()).SignedString([]byte("secret"))
+
+	if err != nil {
+		t.Fatalf("sign token fail. | err: %s", err)
+	}
+
+	if _, err = client.ParseToken(tokenString, &jwt.RegisteredClaims{}); !errors.Is(err, ErrTokenMethodNotAllowed) {
+		t.Fatalf("token of other method accepted. | err: %v", err)
+	}
+
+	tokenString, _ = jwt.NewWithClaims(jwt.SigningMethodHS256, newRotationClaims()).SignedString([]byte("other"))
+
+	if _, err = client.ParseToken(tokenString, &jwt.RegisteredClaims{}); !errors.Is(err, ErrTokenSignatureInvalid) {
+		t.Fatalf("token with bad signature accepted. | err: %v", err)
+	}
+}
@@ -2,9 +2,9 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"github.com/Shopify/sarama"
+	"github.com/dylanpeng/golib/coder"
 	"github.com/dylanpeng/golib/logger"
 	"sync"
 )
@@ -16,6 +16,7 @@ type ProducerConfig struct {
 type Producer struct {
 	c      *ProducerConfig
 	client sarama.AsyncProducer
+	codec  coder.ICodec
 	logger logger.ILogger
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -33,7 +34,7 @@ func (p *Producer) Stop() {
 }
 
 func (p *Producer) Send(topic string, body any, key string) error {
-	payload, err := json.Marshal(body)
+	payload, err := p.codec.Marshal(body)
 
 	if err != nil {
 		p.logger.Errorf("send msg failed. | body: %+v | err: %s", body, err)
@@ -60,6 +61,15 @@ func (p *Producer) Send(topic string, body any, key string) error {
 	return nil
 }
 
+// SetCodec changes the payload encoding, json by default.
+func (p *Producer) SetCodec(codec coder.ICodec) {
+	if codec == nil {
+		return
+	}
+
+	p.codec = codec
+}
+
 func (p *Producer) logErr() {
 	defer p.wg.Done()
 
@@ -76,6 +86,7 @@ func (p *Producer) logErr() {
 func NewProducer(c *ProducerConfig, logger logger.ILogger) (producer *Producer, err error) {
 	producer = &Producer{
 		c:      c,
+		codec:  coder.JsonCoder,
 		logger: logger,
 	}
 
@@ -8,6 +8,7 @@ import (
 	"github.com/apache/rocketmq-client-go/v2/primitive"
 	oProducer "github.com/apache/rocketmq-client-go/v2/producer"
 	"github.com/apache/rocketmq-client-go/v2/rlog"
+	"github.com/dylanpeng/golib/coder"
 	"github.com/dylanpeng/golib/logger"
 )
 
@@ -27,6 +28,17 @@ func (m *Message) String() string {
 	return fmt.Sprintf("%+v", *m)
 }
 
+// NewMessage builds a message whose payload is body encoded with codec.
+func NewMessage(topic string, codec coder.ICodec, body any) (*Message, error) {
+	payload, err := codec.Marshal(body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{Topic: topic, Payload: payload}, nil
+}
+
 func (m *Message) Request() *primitive.Message {
 	msg := primitive.NewMessage(m.Topic, m.Payload)
 
@@ -0,0 +1,207 @@
+package scheduler
+
+import (
+	"errors"
+	"github.com/dylanpeng/golib/coder"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+var ErrFactoryNotFound = errors.New("scheduler job factory not found")
+
+type RescheduleRequest struct {
+	CronExpression string `json:"cron_expression" form:"cron_expression"`
+}
+
+// AddJobRequest adds a job built by the named factory.
+type AddJobRequest struct {
+	Factory        string            `json:"factory" form:"factory"`
+	Name           string            `json:"name" form:"name"`
+	CronExpression string            `json:"cron_expression" form:"cron_expression"`
+	Params         map[string]string `json:"params"`
+}
+
+// JobFactory builds a job from an add request, jobs are code so http can only pick one of the registered kinds.
+type JobFactory func(req *AddJobRequest) (IJob, error)
+
+// AdminRouter exposes job listings and the runtime operations of a Master over http.
+// Protect the group it is registered on, e.g. with jwt.AuthConfig.
+type AdminRouter struct {
+	Master    *Master
+	Coder     coder.ICoder
+	locker    sync.RWMutex
+	factories map[string]JobFactory
+}
+
+// AddFactory registers a factory for POST /jobs under name.
+func (r *AdminRouter) AddFactory(name string, factory JobFactory) {
+	r.locker.Lock()
+	defer r.locker.Unlock()
+
+	r.factories[name] = factory
+}
+
+func (r *AdminRouter) getFactory(name string) (JobFactory, bool) {
+	r.locker.RLock()
+	defer r.locker.RUnlock()
+
+	factory, ok := r.factories[name]
+	return factory, ok
+}
+
+// RegHttpHandler registers the routes on router, usually a group such as app.Group("/admin/scheduler").
+//
+//	GET    /jobs
+//	POST   /jobs                  {"factory": "...", "name": "...", "cron_expression": "...", "params": {}}
+//	GET    /jobs/:name
+//	GET    /jobs/:name/history?limit=
+//	POST   /jobs/:name/pause
+//	POST   /jobs/:name/resume
+//	POST   /jobs/:name/trigger
+//	PUT    /jobs/:name/schedule   {"cron_expression": "..."}
+//	DELETE /jobs/:name
+func (r *AdminRouter) RegHttpHandler(router gin.IRouter) {
+	router.GET("/jobs", r.listJobs)
+	router.POST("/jobs", r.addJob)
+	router.GET("/jobs/:name", r.getJob)
+	router.GET("/jobs/:name/history", r.getHistory)
+	router.POST("/jobs/:name/pause", r.operate(r.Master.PauseJob))
+	router.POST("/jobs/:name/resume", r.operate(r.Master.ResumeJob))
+	router.POST("/jobs/:name/trigger", r.operate(r.Master.TriggerJob))
+	router.PUT("/jobs/:name/schedule", r.reschedule)
+	router.DELETE("/jobs/:name", r.operate(r.Master.RemoveJob))
+}
+
+func (r *AdminRouter) listJobs(ctx *gin.Context) {
+	_ = coder.SendSuccess(ctx, r.Coder, r.Master.GetJobs())
+}
+
+func (r *AdminRouter) addJob(ctx *gin.Context) {
+	req := &AddJobRequest{}
+
+	if err := r.Coder.DecodeRequest(ctx, req); err != nil {
+		r.sendError(ctx, coder.NewError(http.StatusBadRequest, http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	factory, ok := r.getFactory(req.Factory)
+
+	if !ok {
+		r.sendError(ctx, coder.NewError(http.StatusBadRequest, http.StatusBadRequest, ErrFactoryNotFound.Error()))
+		return
+	}
+
+	j, err := factory(req)
+
+	if err == nil {
+		err = r.Master.AddJob(j)
+	}
+
+	if err != nil {
+		if !errors.Is(err, ErrJobExists) {
+			// anything else comes from the factory or parsing the cron expression
+			err = coder.NewError(http.StatusBadRequest, http.StatusBadRequest, err.Error())
+		}
+
+		r.sendError(ctx, err)
+		return
+	}
+
+	r.sendJob(ctx, j.GetName())
+}
+
+func (r *AdminRouter) getJob(ctx *gin.Context) {
+	r.sendJob(ctx, ctx.Param("name"))
+}
+
+func (r *AdminRouter) sendJob(ctx *gin.Context, name string) {
+	status, err := r.Master.GetJob(name)
+
+	if err != nil {
+		r.sendError(ctx, err)
+		return
+	}
+
+	_ = coder.SendSuccess(ctx, r.Coder, status)
+}
+
+func (r *AdminRouter) getHistory(ctx *gin.Context) {
+	limit := DefaultHistoryLimit
+
+	if s := ctx.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+
+		if err != nil || n <= 0 {
+			r.sendError(ctx, coder.NewError(http.StatusBadRequest, http.StatusBadRequest, "invalid limit"))
+			return
+		}
+
+		limit = n
+	}
+
+	records, err := r.Master.GetHistory(ctx.Param("name"), limit)
+
+	if err != nil {
+		r.sendError(ctx, err)
+		return
+	}
+
+	_ = coder.SendSuccess(ctx, r.Coder, records)
+}
+
+func (r *AdminRouter) reschedule(ctx *gin.Context) {
+	req := &RescheduleRequest{}
+
+	if err := r.Coder.DecodeRequest(ctx, req); err != nil {
+		r.sendError(ctx, coder.NewError(http.StatusBadRequest, http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	if req.CronExpression == "" {
+		r.sendError(ctx, coder.NewError(http.StatusBadRequest, http.StatusBadRequest, "cron_expression required"))
+		return
+	}
+
+	if err := r.Master.RescheduleJob(ctx.Param("name"), req.CronExpression); err != nil {
+		if !errors.Is(err, ErrJobNotFound) {
+			// anything else comes from parsing the cron expression
+			err = coder.NewError(http.StatusBadRequest, http.StatusBadRequest, err.Error())
+		}
+
+		r.sendError(ctx, err)
+		return
+	}
+
+	r.getJob(ctx)
+}
+
+func (r *AdminRouter) operate(do func(name string) error) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := do(ctx.Param("name")); err != nil {
+			r.sendError(ctx, err)
+			return
+		}
+
+		_ = coder.SendSuccess(ctx, r.Coder, nil)
+	}
+}
+
+func (r *AdminRouter) sendError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		err = coder.NewError(http.StatusNotFound, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrJobExists), errors.Is(err, ErrTickSkipped):
+		err = coder.NewError(http.StatusConflict, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrMasterStopped):
+		err = coder.NewError(http.StatusServiceUnavailable, http.StatusServiceUnavailable, err.Error())
+	}
+
+	_ = coder.SendError(ctx, r.Coder, err)
+}
+
+// NewAdminRouter answers with the negotiated coder.
+func NewAdminRouter(m *Master) *AdminRouter {
+	return &AdminRouter{Master: m, Coder: coder.DefaultRegistry, factories: make(map[string]JobFactory)}
+}
@@ -0,0 +1,193 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newIdleJob(name string) *policyJob {
+	job := &policyJob{Provider: Provider{Name: name, CronExpression: "0 0 0 1 1 *"}, policy: &Policy{}}
+	job.do = func(run int32) error {
+		return nil
+	}
+
+	return job
+}
+
+func TestMaster_Manage(t *testing.T) {
+	job := newIdleJob("job")
+	m := NewJobMaster([]IJob{job})
+	m.Start()
+	defer m.Stop(context.Background())
+
+	if err := m.TriggerJob("job"); err != nil {
+		t.Fatalf("trigger job fail. | err: %s", err)
+	}
+
+	for deadline := time.Now().Add(time.Second); atomic.LoadInt32(&job.runs) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatalf("triggered job did not run")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := m.PauseJob("job"); err != nil {
+		t.Fatalf("pause job fail. | err: %s", err)
+	}
+
+	if status, _ := m.GetJob("job"); !status.Paused || !status.NextRun.IsZero() {
+		t.Fatalf("unexpected paused status. | status: %+v", status)
+	}
+
+	if err := m.ResumeJob("job"); err != nil {
+		t.Fatalf("resume job fail. | err: %s", err)
+	}
+
+	if status, _ := m.GetJob("job"); status.Paused || status.NextRun.IsZero() {
+		t.Fatalf("unexpected resumed status. | status: %+v", status)
+	}
+
+	if err := m.RescheduleJob("job", "invalid"); err == nil {
+		t.Fatalf("expected invalid cron expression error")
+	}
+
+	if err := m.RescheduleJob("job", "*/10 * * * * *"); err != nil {
+		t.Fatalf("reschedule job fail. | err: %s", err)
+	}
+
+	if status, _ := m.GetJob("job"); status.CronExpression != "*/10 * * * * *" || time.Until(status.NextRun) > 10*time.Second {
+		t.Fatalf("unexpected rescheduled status. | status: %+v", status)
+	}
+
+	if len(m.cronScheduler.Jobs()) != 1 {
+		t.Fatalf("unexpected scheduled jobs. | count: %d", len(m.cronScheduler.Jobs()))
+	}
+
+	if err := m.AddJob(newIdleJob("job")); !errors.Is(err, ErrJobExists) {
+		t.Fatalf("expected job exists. | err: %v", err)
+	}
+
+	if err := m.AddJob(newIdleJob("other")); err != nil {
+		t.Fatalf("add job fail. | err: %s", err)
+	}
+
+	if err := m.RemoveJob("job"); err != nil {
+		t.Fatalf("remove job fail. | err: %s", err)
+	}
+
+	if jobs := m.GetJobs(); len(jobs) != 1 || jobs[0].Name != "other" || jobs[0].NextRun.IsZero() {
+		t.Fatalf("unexpected jobs. | jobs: %+v", jobs)
+	}
+
+	if err := m.PauseJob("job"); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("expected job not found. | err: %v", err)
+	}
+}
+
+func TestMaster_TriggerSkipped(t *testing.T) {
+	release := make(chan struct{})
+	job := newIdleJob("job")
+	job.policy = &Policy{Overlap: OverlapSkip}
+	job.do = func(run int32) error {
+		<-release
+		return nil
+	}
+
+	locker := NewMemoryLocker()
+	m := NewJobMaster([]IJob{job, newIdleJob("other")})
+	m.SetLocker(locker)
+	defer m.Stop(context.Background())
+	defer close(release)
+
+	if err := m.TriggerJob("job"); err != nil {
+		t.Fatalf("trigger job fail. | err: %s", err)
+	}
+
+	if err := m.TriggerJob("job"); !errors.Is(err, ErrTickSkipped) {
+		t.Fatalf("expected skipped trigger while running. | err: %v", err)
+	}
+
+	// in distributed mode a trigger that loses the claim of its second is reported
+	now := time.Now().Truncate(time.Second)
+	_, _ = locker.Acquire("other", now, time.Minute)
+	_, _ = locker.Acquire("other", now.Add(time.Second), time.Minute)
+
+	if err := m.TriggerJob("other"); !errors.Is(err, ErrTickSkipped) {
+		t.Fatalf("expected skipped trigger of a claimed second. | err: %v", err)
+	}
+}
+
+func TestAdminRouter(t *testing.T) {
+	m := NewJobMaster([]IJob{newIdleJob("job")})
+	m.SetLocker(NewMemoryLocker())
+	m.Start()
+	defer m.Stop(context.Background())
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	admin := NewAdminRouter(m)
+	admin.AddFactory("idle", func(req *AddJobRequest) (IJob, error) {
+		job := newIdleJob(req.Name)
+		job.CronExpression = req.CronExpression
+		return job, nil
+	})
+	admin.RegHttpHandler(router.Group("/admin"))
+
+	cases := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{http.MethodGet, "/admin/jobs", "", http.StatusOK},
+		{http.MethodGet, "/admin/jobs/job", "", http.StatusOK},
+		{http.MethodGet, "/admin/jobs/missing", "", http.StatusNotFound},
+		{http.MethodGet, "/admin/jobs/job/history?limit=5", "", http.StatusOK},
+		{http.MethodGet, "/admin/jobs/job/history?limit=x", "", http.StatusBadRequest},
+		{http.MethodPost, "/admin/jobs/job/pause", "", http.StatusOK},
+		{http.MethodPost, "/admin/jobs/job/resume", "", http.StatusOK},
+		{http.MethodPost, "/admin/jobs/missing/trigger", "", http.StatusNotFound},
+		{http.MethodPut, "/admin/jobs/job/schedule", `{"cron_expression":"invalid"}`, http.StatusBadRequest},
+		{http.MethodPut, "/admin/jobs/job/schedule", `{"cron_expression":"*/10 * * * * *"}`, http.StatusOK},
+		{http.MethodDelete, "/admin/jobs/job", "", http.StatusOK},
+		{http.MethodDelete, "/admin/jobs/job", "", http.StatusNotFound},
+		{http.MethodPost, "/admin/jobs", `{"factory":"idle","name":"added","cron_expression":"*/10 * * * * *"}`, http.StatusOK},
+		{http.MethodPost, "/admin/jobs", `{"factory":"idle","name":"added","cron_expression":"*/10 * * * * *"}`, http.StatusConflict},
+		{http.MethodPost, "/admin/jobs", `{"factory":"idle","name":"invalid","cron_expression":"invalid"}`, http.StatusBadRequest},
+		{http.MethodPost, "/admin/jobs", `{"factory":"missing","name":"other"}`, http.StatusBadRequest},
+		{http.MethodGet, "/admin/jobs/added", "", http.StatusOK},
+	}
+
+	for i, c := range cases {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != c.status {
+			t.Fatalf("case %d unexpected status. | status: %d | body: %s", i, w.Code, w.Body.String())
+		}
+	}
+
+	// a trigger that does not run answers 409
+	now := time.Now().Truncate(time.Second)
+
+	for _, tick := range []time.Time{now, now.Add(time.Second)} {
+		_, _ = m.locker.Acquire("added", tick, time.Minute)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/jobs/added/trigger", nil))
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("unexpected trigger status. | status: %d | body: %s", w.Code, w.Body.String())
+	}
+}
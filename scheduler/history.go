@@ -0,0 +1,139 @@
+package scheduler
+
+import (
+	"gorm.io/gorm"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	RunStatusSuccess = "success"
+	RunStatusFailed  = "failed"
+)
+
+const DefaultHistoryLimit = 100
+
+// RunRecord is one tick of a job including its retries. Error and Panic describe the last attempt.
+type RunRecord struct {
+	Id           uint64        `gorm:"primaryKey;autoIncrement" json:"id"`
+	Job          string        `gorm:"size:128;index:idx_job_start" json:"job"`
+	Instance     string        `gorm:"size:128" json:"instance"`
+	Tick         time.Time     `json:"tick"`
+	StartTime    time.Time     `gorm:"index:idx_job_start" json:"start_time"`
+	EndTime      time.Time     `json:"end_time"`
+	Duration     time.Duration `json:"duration"`
+	Attempts     int           `json:"attempts"`
+	Status       string        `gorm:"size:16" json:"status"`
+	Error        string        `gorm:"type:text" json:"error"`
+	Panic        bool          `json:"panic"`
+	FencingToken int64         `json:"fencing_token"`
+}
+
+func (r *RunRecord) TableName() string {
+	return "scheduler_run_record"
+}
+
+// IHistoryStore keeps run records. List returns the latest limit records of job, newest first.
+type IHistoryStore interface {
+	Save(record *RunRecord) error
+	List(job string, limit int) ([]*RunRecord, error)
+}
+
+// IRunHook observes job runs, e.g. to export metrics. Hooks are called synchronously and must be fast.
+type IRunHook interface {
+	OnStart(job string, tick time.Time)
+	OnFinish(record *RunRecord)
+}
+
+// MemoryHistoryStore keeps the latest Limit records per job in process memory.
+type MemoryHistoryStore struct {
+	locker  sync.RWMutex
+	records map[string][]*RunRecord
+	lastId  uint64
+	Limit   int
+}
+
+func (s *MemoryHistoryStore) Save(record *RunRecord) error {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	s.lastId++
+	record.Id = s.lastId
+	records := append(s.records[record.Job], record)
+
+	if s.Limit > 0 && len(records) > s.Limit {
+		records = records[len(records)-s.Limit:]
+	}
+
+	s.records[record.Job] = records
+	return nil
+}
+
+func (s *MemoryHistoryStore) List(job string, limit int) ([]*RunRecord, error) {
+	s.locker.RLock()
+	defer s.locker.RUnlock()
+
+	records := s.records[job]
+
+	if limit <= 0 || limit > len(records) {
+		limit = len(records)
+	}
+
+	result := make([]*RunRecord, 0, limit)
+
+	for i := len(records) - 1; i >= len(records)-limit; i-- {
+		copied := *records[i]
+		result = append(result, &copied)
+	}
+
+	return result, nil
+}
+
+func NewMemoryHistoryStore(limit int) *MemoryHistoryStore {
+	return &MemoryHistoryStore{records: make(map[string][]*RunRecord), Limit: limit}
+}
+
+// GormHistoryStore keeps records in the scheduler_run_record table, call AutoMigrate to create it.
+type GormHistoryStore struct {
+	db *gorm.DB
+}
+
+func (s *GormHistoryStore) AutoMigrate() error {
+	return s.db.AutoMigrate(&RunRecord{})
+}
+
+func (s *GormHistoryStore) Save(record *RunRecord) error {
+	return s.db.Create(record).Error
+}
+
+func (s *GormHistoryStore) List(job string, limit int) (result []*RunRecord, err error) {
+	query := s.db.Where("job = ?", job).Order("start_time desc, id desc")
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	err = query.Find(&result).Error
+	return
+}
+
+func NewGormHistoryStore(db *gorm.DB) *GormHistoryStore {
+	return &GormHistoryStore{db: db}
+}
+
+// JobStatus is the query view of a job.
+type JobStatus struct {
+	Name           string     `json:"name"`
+	CronExpression string     `json:"cron_expression"`
+	Paused         bool       `json:"paused"`
+	Running        bool       `json:"running"`
+	NextRun        time.Time  `json:"next_run"`
+	LastRun        *RunRecord `json:"last_run,omitempty"`
+}
+
+func sortJobStatus(list []*JobStatus) {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+}
@@ -0,0 +1,144 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type countHook struct {
+	locker   sync.Mutex
+	started  int
+	finished []*RunRecord
+}
+
+func (h *countHook) OnStart(job string, tick time.Time) {
+	h.locker.Lock()
+	defer h.locker.Unlock()
+	h.started++
+}
+
+func (h *countHook) OnFinish(record *RunRecord) {
+	h.locker.Lock()
+	defer h.locker.Unlock()
+	h.finished = append(h.finished, record)
+}
+
+func TestMemoryHistoryStore(t *testing.T) {
+	store := NewMemoryHistoryStore(2)
+
+	for i := 1; i <= 3; i++ {
+		if err := store.Save(&RunRecord{Job: "job", Attempts: i}); err != nil {
+			t.Fatalf("save record fail. | err: %s", err)
+		}
+	}
+
+	records, err := store.List("job", 0)
+
+	if err != nil {
+		t.Fatalf("list records fail. | err: %s", err)
+	}
+
+	if len(records) != 2 || records[0].Attempts != 3 || records[1].Attempts != 2 {
+		t.Fatalf("unexpected records. | records: %+v", records)
+	}
+
+	if records, _ = store.List("job", 1); len(records) != 1 || records[0].Attempts != 3 {
+		t.Fatalf("unexpected limited records. | records: %+v", records)
+	}
+}
+
+func TestMaster_History(t *testing.T) {
+	job := &policyJob{Provider: Provider{Name: "job", CronExpression: "*/5 * * * * *"}, policy: &Policy{RetryCount: 1, RetryInterval: time.Millisecond}}
+	job.do = func(run int32) error {
+		switch run {
+		case 1:
+			return nil
+		case 2:
+			return errors.New("job fail")
+		}
+
+		panic("job panic")
+	}
+
+	hook := &countHook{}
+	m := NewJobMaster([]IJob{job})
+	m.SetInstance("test")
+	m.AddHook(hook)
+
+	m.execute(m.jobs["job"])
+	m.execute(m.jobs["job"])
+
+	records, err := m.GetHistory("job", 10)
+
+	if err != nil {
+		t.Fatalf("get history fail. | err: %s", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("unexpected record count. | count: %d", len(records))
+	}
+
+	failed, success := records[0], records[1]
+
+	if success.Status != RunStatusSuccess || success.Attempts != 1 || success.Instance != "test" || success.EndTime.Before(success.StartTime) {
+		t.Fatalf("unexpected success record. | record: %+v", success)
+	}
+
+	if failed.Status != RunStatusFailed || failed.Attempts != 2 || !failed.Panic || failed.Error == "" {
+		t.Fatalf("unexpected failed record. | record: %+v", failed)
+	}
+
+	if hook.started != 2 || len(hook.finished) != 2 {
+		t.Fatalf("unexpected hook calls. | started: %d | finished: %d", hook.started, len(hook.finished))
+	}
+
+	if _, err = m.GetHistory("missing", 1); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("expected job not found. | err: %v", err)
+	}
+
+	m.Start()
+	defer m.Stop(context.Background())
+
+	jobs := m.GetJobs()
+
+	if len(jobs) != 1 || jobs[0].NextRun.IsZero() || jobs[0].LastRun == nil || jobs[0].LastRun.Id != failed.Id {
+		t.Fatalf("unexpected job status. | jobs: %+v", jobs)
+	}
+}
+
+type panicHook struct{}
+
+func (h *panicHook) OnStart(job string, tick time.Time) {
+	panic("hook start panic")
+}
+
+func (h *panicHook) OnFinish(record *RunRecord) {
+	panic("hook finish panic")
+}
+
+type panicHistoryStore struct {
+	IHistoryStore
+}
+
+func (s *panicHistoryStore) Save(record *RunRecord) error {
+	panic("save panic")
+}
+
+func TestMaster_HookPanic(t *testing.T) {
+	job := newIdleJob("job")
+	hook := &countHook{}
+	m := NewJobMaster([]IJob{job})
+	m.SetHistoryStore(&panicHistoryStore{IHistoryStore: NewMemoryHistoryStore(DefaultHistoryLimit)})
+	m.AddHook(&panicHook{})
+	m.AddHook(hook)
+
+	// the panics are logged, the job and the other hooks still run
+	m.execute(m.jobs["job"])
+
+	if job.runs != 1 || hook.started != 1 || len(hook.finished) != 1 {
+		t.Fatalf("unexpected run. | runs: %d | started: %d | finished: %d", job.runs, hook.started, len(hook.finished))
+	}
+}
@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+// IJob is a context aware provider. The context is cancelled on timeout, on Stop and when the
+// distributed claim of the tick is lost.
+type IJob interface {
+	GetName() string
+	GetCronExpression() string
+	Run(ctx context.Context) error
+	String() string
+}
+
+// ITimeoutProvider bounds a tick including its retries, no timeout when it returns 0.
+type ITimeoutProvider interface {
+	GetTimeout() time.Duration
+}
+
+type fencingTokenKey struct{}
+
+// FencingToken returns the fencing token of the tick in distributed mode. Pass it to the storage
+// the job writes to, so writes of a previous owner whose claim expired can be rejected.
+func FencingToken(ctx context.Context) (token int64, ok bool) {
+	token, ok = ctx.Value(fencingTokenKey{}).(int64)
+	return
+}
+
+// AdaptProvider runs a legacy provider as a job. Run ignores the context, so timeouts and Stop
+// do not interrupt it, Stop still waits for it.
+func AdaptProvider(p IProvider) IJob {
+	return &providerJob{IProvider: p}
+}
+
+type providerJob struct {
+	IProvider
+}
+
+func (j *providerJob) Run(ctx context.Context) error {
+	j.IProvider.Run()
+	return nil
+}
+
+func (j *providerJob) GetLockTtl() time.Duration {
+	return getLockTtl(j.IProvider)
+}
+
+func (j *providerJob) GetTimeout() time.Duration {
+	return getTimeout(j.IProvider)
+}
+
+func (j *providerJob) GetPolicy() *Policy {
+	return getPolicy(j.IProvider)
+}
+
+func getTimeout(p any) time.Duration {
+	if t, ok := p.(ITimeoutProvider); ok {
+		return t.GetTimeout()
+	}
+
+	return 0
+}
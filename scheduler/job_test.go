@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type blockJob struct {
+	Provider
+	started  chan struct{}
+	finished int32
+	errs     chan error
+}
+
+func (j *blockJob) Run(ctx context.Context) error {
+	select {
+	case j.started <- struct{}{}:
+	default:
+	}
+
+	<-ctx.Done()
+	atomic.AddInt32(&j.finished, 1)
+
+	select {
+	case j.errs <- ctx.Err():
+	default:
+	}
+
+	return ctx.Err()
+}
+
+type sleepProvider struct {
+	Provider
+	started chan struct{}
+}
+
+func (p *sleepProvider) Run() {
+	select {
+	case p.started <- struct{}{}:
+	default:
+	}
+
+	time.Sleep(time.Second)
+}
+
+func TestMaster_Timeout(t *testing.T) {
+	job := &blockJob{Provider: Provider{Name: "block", CronExpression: "* * * * * *", Timeout: 1}, started: make(chan struct{}, 1), errs: make(chan error, 1)}
+	master := NewJobMaster([]IJob{job})
+	master.Start()
+
+	<-job.started
+
+	select {
+	case err := <-job.errs:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("job not timed out. | err: %v", err)
+		}
+	case <-time.After(1500 * time.Millisecond):
+		t.Fatalf("job not timed out")
+	}
+
+	_ = master.Stop(context.Background())
+}
+
+func TestMaster_Stop(t *testing.T) {
+	job := &blockJob{Provider: Provider{Name: "block", CronExpression: "* * * * * *"}, started: make(chan struct{}, 1), errs: make(chan error, 1)}
+	master := NewJobMaster([]IJob{job})
+	master.Start()
+	<-job.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := master.Stop(ctx); err != nil || atomic.LoadInt32(&job.finished) == 0 {
+		t.Fatalf("stop did not cancel the running job. | err: %v", err)
+	}
+
+	if err := <-job.errs; !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected job error. | err: %v", err)
+	}
+
+	legacy := &sleepProvider{Provider: Provider{Name: "sleep", CronExpression: "* * * * * *"}, started: make(chan struct{}, 1)}
+	master = NewMaster([]IProvider{legacy})
+	master.Start()
+	<-legacy.started
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if err := master.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("stop returned before the legacy job finished. | err: %v", err)
+	}
+}
+
+type slowLocker struct {
+	*MemoryLocker
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (l *slowLocker) Acquire(name string, tick time.Time, ttl time.Duration) (ILease, error) {
+	close(l.entered)
+	<-l.release
+	return l.MemoryLocker.Acquire(name, tick, ttl)
+}
+
+func TestMaster_StopTrigger(t *testing.T) {
+	locker := &slowLocker{MemoryLocker: NewMemoryLocker(), entered: make(chan struct{}), release: make(chan struct{})}
+	master := NewJobMaster([]IJob{newIdleJob("job")})
+	master.SetLocker(locker)
+
+	go func() {
+		_ = master.TriggerJob("job")
+	}()
+
+	<-locker.entered
+	stopped := make(chan struct{})
+
+	go func() {
+		_ = master.Stop(context.Background())
+		close(stopped)
+	}()
+
+	// a trigger claiming its tick holds Stop
+	select {
+	case <-stopped:
+		t.Fatalf("stop returned while a trigger was claiming")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(locker.release)
+	<-stopped
+
+	if err := master.TriggerJob("job"); !errors.Is(err, ErrMasterStopped) {
+		t.Fatalf("expected master stopped. | err: %v", err)
+	}
+}
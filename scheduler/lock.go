@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const DefaultLockTtl = time.Minute
+
+var ErrLeaseLost = errors.New("scheduler lease lost")
+
+// ILocker claims job ticks across instances. Acquire returns a nil lease when another instance
+// already claimed the tick. A claim is never released early, it expires ttl after the last renew,
+// so the same tick can not be claimed again while the owner runs or shortly after.
+type ILocker interface {
+	Acquire(name string, tick time.Time, ttl time.Duration) (ILease, error)
+}
+
+// ILease is a claimed tick. Token is a fencing token that grows with every claim of the job,
+// Renew fails with ErrLeaseLost once the claim expired or was taken over.
+type ILease interface {
+	Token() int64
+	Renew(ttl time.Duration) error
+	Done() error
+}
+
+// IRunningLocker is implemented by lockers that can hold a per job lock for a whole run, so
+// OverlapSkip skips a tick while the previous one still runs on any instance.
+// AcquireRunning returns a nil lease when the job is running elsewhere, Done releases the lock at once.
+type IRunningLocker interface {
+	AcquireRunning(name string, ttl time.Duration) (ILease, error)
+}
+
+// ILockTtlProvider lets a provider set the claim ttl, it should exceed the usual job duration.
+// Long runs are covered by renewing the claim every third of the ttl.
+type ILockTtlProvider interface {
+	GetLockTtl() time.Duration
+}
+
+func getLockTtl(p any) time.Duration {
+	if t, ok := p.(ILockTtlProvider); ok && t.GetLockTtl() > 0 {
+		return t.GetLockTtl()
+	}
+
+	return DefaultLockTtl
+}
+
+func tickKey(name string, tick time.Time) string {
+	return name + ":" + strconv.FormatInt(tick.Unix(), 10)
+}
+
+func runningKey(name string) string {
+	return name + ":running"
+}
+
+// MemoryLocker claims ticks in process memory, for several masters in one process and tests.
+type MemoryLocker struct {
+	locker sync.Mutex
+	claims map[string]*memoryLease
+	fences map[string]int64
+}
+
+type memoryLease struct {
+	locker   *MemoryLocker
+	key      string
+	token    int64
+	expireAt time.Time
+	release  bool
+}
+
+func (l *MemoryLocker) Acquire(name string, tick time.Time, ttl time.Duration) (ILease, error) {
+	return l.claim(name, tickKey(name, tick), ttl, false), nil
+}
+
+func (l *MemoryLocker) AcquireRunning(name string, ttl time.Duration) (ILease, error) {
+	return l.claim(name, runningKey(name), ttl, true), nil
+}
+
+func (l *MemoryLocker) claim(name, key string, ttl time.Duration, release bool) ILease {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	now := time.Now()
+
+	for k, c := range l.claims {
+		if now.After(c.expireAt) {
+			delete(l.claims, k)
+		}
+	}
+
+	if _, ok := l.claims[key]; ok {
+		return nil
+	}
+
+	l.fences[name]++
+	lease := &memoryLease{locker: l, key: key, token: l.fences[name], expireAt: now.Add(ttl), release: release}
+	l.claims[key] = lease
+	return lease
+}
+
+func (l *memoryLease) Token() int64 {
+	return l.token
+}
+
+func (l *memoryLease) Renew(ttl time.Duration) error {
+	l.locker.locker.Lock()
+	defer l.locker.locker.Unlock()
+
+	now := time.Now()
+
+	if c, ok := l.locker.claims[l.key]; !ok || c != l || now.After(l.expireAt) {
+		return ErrLeaseLost
+	}
+
+	l.expireAt = now.Add(ttl)
+	return nil
+}
+
+func (l *memoryLease) Done() error {
+	if !l.release {
+		return nil
+	}
+
+	l.locker.locker.Lock()
+	defer l.locker.locker.Unlock()
+
+	if c, ok := l.locker.claims[l.key]; ok && c == l {
+		delete(l.locker.claims, l.key)
+	}
+
+	return nil
+}
+
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{claims: make(map[string]*memoryLease), fences: make(map[string]int64)}
+}
@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"github.com/dylanpeng/golib/etcd"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"math"
+	"time"
+)
+
+// EtcdLocker claims ticks with a create-if-absent transaction bound to a lease.
+// The fencing token is the etcd revision of the claim, which only grows.
+type EtcdLocker struct {
+	client *etcd.Client
+	Prefix string
+}
+
+type etcdLease struct {
+	locker  *EtcdLocker
+	leaseId clientv3.LeaseID
+	token   int64
+	release bool
+}
+
+func (l *EtcdLocker) Acquire(name string, tick time.Time, ttl time.Duration) (ILease, error) {
+	return l.claim(tickKey(name, tick), ttl, false)
+}
+
+func (l *EtcdLocker) AcquireRunning(name string, ttl time.Duration) (ILease, error) {
+	return l.claim(runningKey(name), ttl, true)
+}
+
+func (l *EtcdLocker) claim(key string, ttl time.Duration, release bool) (ILease, error) {
+	ctx := context.Background()
+	client := l.client.GetEtcdClient()
+	grant, err := client.Grant(ctx, int64(math.Max(1, math.Ceil(ttl.Seconds()))))
+
+	if err != nil {
+		return nil, err
+	}
+
+	key = l.Prefix + key
+	rsp, err := client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, "", clientv3.WithLease(grant.ID))).
+		Commit()
+
+	if err != nil || !rsp.Succeeded {
+		_, _ = client.Revoke(ctx, grant.ID)
+		return nil, err
+	}
+
+	return &etcdLease{locker: l, leaseId: grant.ID, token: rsp.Header.Revision, release: release}, nil
+}
+
+func (l *etcdLease) Token() int64 {
+	return l.token
+}
+
+// Renew keeps the lease alive for its granted ttl, the ttl argument is ignored.
+func (l *etcdLease) Renew(ttl time.Duration) error {
+	_, err := l.locker.client.GetEtcdClient().KeepAliveOnce(context.Background(), l.leaseId)
+
+	if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+		return ErrLeaseLost
+	}
+
+	return err
+}
+
+// Done revokes the lease of a running lock, which deletes its key.
+func (l *etcdLease) Done() error {
+	if !l.release {
+		return nil
+	}
+
+	_, err := l.locker.client.GetEtcdClient().Revoke(context.Background(), l.leaseId)
+
+	if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+func NewEtcdLocker(client *etcd.Client) *EtcdLocker {
+	return &EtcdLocker{client: client, Prefix: "/golib/scheduler/"}
+}
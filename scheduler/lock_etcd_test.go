@@ -0,0 +1,37 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/dylanpeng/golib/etcd"
+	"testing"
+	"time"
+)
+
+func TestEtcdLocker(t *testing.T) {
+	skipUnreachable(t, "127.0.0.1:2379")
+
+	client, err := etcd.NewClient(&etcd.Config{Addrs: []string{"127.0.0.1:2379"}, Timeout: 5})
+
+	if err != nil {
+		t.Fatalf("new etcd client fail. | err: %s", err)
+	}
+
+	defer client.Close()
+	locker := NewEtcdLocker(client)
+	name, lease := testLockerClaim(t, locker)
+
+	// a revoked lease drops the claim, so renew reports the loss and the tick can be claimed again
+	if _, err = client.GetEtcdClient().Revoke(context.Background(), lease.(*etcdLease).leaseId); err != nil {
+		t.Fatalf("revoke lease fail. | err: %s", err)
+	}
+
+	if err = lease.Renew(5 * time.Second); err != ErrLeaseLost {
+		t.Fatalf("renewed a revoked lease. | err: %v", err)
+	}
+
+	next, err := locker.Acquire(name, time.Now().Truncate(time.Second).Add(-time.Second), 5*time.Second)
+
+	if err != nil || next == nil || next.Token() <= lease.Token() {
+		t.Fatalf("fencing token not increased. | err: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/dylanpeng/golib/redis"
+	"strconv"
+	"time"
+)
+
+const (
+	renewScript   = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) else return 0 end`
+	releaseScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`
+)
+
+// RedisLocker claims ticks with SET NX PX, fencing tokens come from a per job INCR counter.
+type RedisLocker struct {
+	pool   *redis.Pool
+	name   string
+	Prefix string
+}
+
+type redisLease struct {
+	locker  *RedisLocker
+	key     string
+	token   int64
+	release bool
+}
+
+func (l *RedisLocker) Acquire(name string, tick time.Time, ttl time.Duration) (ILease, error) {
+	return l.claim(name, tickKey(name, tick), ttl, false)
+}
+
+func (l *RedisLocker) AcquireRunning(name string, ttl time.Duration) (ILease, error) {
+	return l.claim(name, runningKey(name), ttl, true)
+}
+
+func (l *RedisLocker) claim(name, key string, ttl time.Duration, release bool) (ILease, error) {
+	client, err := l.pool.Get(l.name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	token, err := client.Incr(ctx, l.Prefix+name+":fence").Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	key = l.Prefix + key
+	ok, err := client.SetNX(ctx, key, token, ttl).Result()
+
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	return &redisLease{locker: l, key: key, token: token, release: release}, nil
+}
+
+func (l *redisLease) Token() int64 {
+	return l.token
+}
+
+func (l *redisLease) Renew(ttl time.Duration) error {
+	client, err := l.locker.pool.Get(l.locker.name)
+
+	if err != nil {
+		return err
+	}
+
+	result, err := client.Eval(context.Background(), renewScript, []string{l.key}, strconv.FormatInt(l.token, 10), ttl.Milliseconds()).Int()
+
+	if err != nil {
+		return err
+	}
+
+	if result == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+func (l *redisLease) Done() error {
+	if !l.release {
+		return nil
+	}
+
+	client, err := l.locker.pool.Get(l.locker.name)
+
+	if err != nil {
+		return err
+	}
+
+	return client.Eval(context.Background(), releaseScript, []string{l.key}, strconv.FormatInt(l.token, 10)).Err()
+}
+
+// NewRedisLocker claims ticks with the named client of pool.
+func NewRedisLocker(pool *redis.Pool, name string) *RedisLocker {
+	return &RedisLocker{pool: pool, name: name, Prefix: "golib:scheduler:"}
+}
@@ -0,0 +1,36 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/dylanpeng/golib/redis"
+	"testing"
+	"time"
+)
+
+func TestRedisLocker(t *testing.T) {
+	conf := &redis.Config{Host: "127.0.0.1", Port: 6379}
+	skipUnreachable(t, conf.GetAddr())
+
+	pool := redis.NewPool()
+	pool.Add("test", conf)
+	locker := NewRedisLocker(pool, "test")
+	_, lease := testLockerClaim(t, locker)
+
+	// another owner took the key after it expired, the token compare of the renew script must fail
+	client, _ := pool.Get("test")
+	key := lease.(*redisLease).key
+
+	if err := client.Set(context.Background(), key, lease.Token()+100, time.Minute).Err(); err != nil {
+		t.Fatalf("overwrite claim fail. | err: %s", err)
+	}
+
+	if err := lease.Renew(5 * time.Second); err != ErrLeaseLost {
+		t.Fatalf("renewed a lease of another owner. | err: %v", err)
+	}
+
+	client.Del(context.Background(), key)
+
+	if err := lease.Renew(5 * time.Second); err != ErrLeaseLost {
+		t.Fatalf("renewed an expired lease. | err: %v", err)
+	}
+}
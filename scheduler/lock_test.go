@@ -0,0 +1,146 @@
+package scheduler
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type countProvider struct {
+	Provider
+	locker sync.Mutex
+	ticks  map[int64]int
+}
+
+func (p *countProvider) Run() {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	p.ticks[time.Now().Unix()]++
+}
+
+func TestMaster_Distributed(t *testing.T) {
+	provider := &countProvider{Provider: Provider{Name: "count", CronExpression: "* * * * * *"}, ticks: make(map[int64]int)}
+	locker := NewMemoryLocker()
+	masters := make([]*Master, 0, 3)
+
+	for i := 0; i < 3; i++ {
+		m := NewMaster([]IProvider{provider})
+		m.SetLocker(locker)
+		m.Start()
+		masters = append(masters, m)
+	}
+
+	time.Sleep(2500 * time.Millisecond)
+
+	for _, m := range masters {
+		_ = m.Stop(context.Background())
+	}
+
+	provider.locker.Lock()
+	defer provider.locker.Unlock()
+
+	if len(provider.ticks) == 0 {
+		t.Fatalf("job never ran")
+	}
+
+	for tick, count := range provider.ticks {
+		if count != 1 {
+			t.Fatalf("tick ran more than once. | tick: %d | count: %d", tick, count)
+		}
+	}
+}
+
+func TestMemoryLocker(t *testing.T) {
+	locker := NewMemoryLocker()
+	tick := time.Now().Truncate(time.Second)
+	lease, err := locker.Acquire("job", tick, 20*time.Millisecond)
+
+	if err != nil || lease == nil {
+		t.Fatalf("acquire fail. | err: %v", err)
+	}
+
+	if other, _ := locker.Acquire("job", tick, time.Minute); other != nil {
+		t.Fatalf("tick claimed twice")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if err = lease.Renew(time.Minute); err != ErrLeaseLost {
+		t.Fatalf("expired lease renewed. | err: %v", err)
+	}
+
+	next, _ := locker.Acquire("job", tick, time.Minute)
+
+	if next == nil || next.Token() <= lease.Token() {
+		t.Fatalf("fencing token not increased")
+	}
+}
+
+// skipUnreachable skips backend tests when the backend is not running locally.
+func skipUnreachable(t *testing.T, addr string) {
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+
+	if err != nil {
+		t.Skipf("%s not reachable. | err: %s", addr, err)
+	}
+
+	_ = conn.Close()
+}
+
+// testLockerClaim checks a tick is claimed once, fencing tokens grow and a fresh lease renews.
+func testLockerClaim(t *testing.T, locker ILocker) (name string, lease ILease) {
+	name = "lock-test-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	tick := time.Now().Truncate(time.Second)
+	lease, err := locker.Acquire(name, tick, 5*time.Second)
+
+	if err != nil || lease == nil {
+		t.Fatalf("acquire fail. | err: %v", err)
+	}
+
+	if other, err := locker.Acquire(name, tick, 5*time.Second); err != nil || other != nil {
+		t.Fatalf("tick claimed twice. | err: %v", err)
+	}
+
+	next, err := locker.Acquire(name, tick.Add(time.Second), 5*time.Second)
+
+	if err != nil || next == nil || next.Token() <= lease.Token() {
+		t.Fatalf("fencing token not increased. | err: %v", err)
+	}
+
+	_ = next.Done()
+
+	if err = lease.Renew(5 * time.Second); err != nil {
+		t.Fatalf("renew fail. | err: %s", err)
+	}
+
+	runningLocker, ok := locker.(IRunningLocker)
+
+	if !ok {
+		return
+	}
+
+	running, err := runningLocker.AcquireRunning(name, 5*time.Second)
+
+	if err != nil || running == nil {
+		t.Fatalf("acquire running lock fail. | err: %v", err)
+	}
+
+	if other, err := runningLocker.AcquireRunning(name, 5*time.Second); err != nil || other != nil {
+		t.Fatalf("running lock held twice. | err: %v", err)
+	}
+
+	if err = running.Done(); err != nil {
+		t.Fatalf("release running lock fail. | err: %s", err)
+	}
+
+	if running, err = runningLocker.AcquireRunning(name, 5*time.Second); err != nil || running == nil {
+		t.Fatalf("running lock not released. | err: %v", err)
+	}
+
+	_ = running.Done()
+	return
+}
@@ -0,0 +1,132 @@
+package scheduler
+
+import (
+	"errors"
+	"github.com/dylanpeng/golib/zookeeper"
+	"github.com/go-zookeeper/zk"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ZookeeperLocker claims ticks with ephemeral nodes, the fencing token is the node czxid.
+// Zookeeper has no node ttl, Done deletes the node ttl later and a lost session drops it at once.
+type ZookeeperLocker struct {
+	client *zookeeper.Client
+	Prefix string
+}
+
+type zookeeperLease struct {
+	locker  *ZookeeperLocker
+	path    string
+	token   int64
+	ttl     time.Duration
+	release bool
+}
+
+func (l *ZookeeperLocker) Acquire(name string, tick time.Time, ttl time.Duration) (ILease, error) {
+	return l.claim(name, strconv.FormatInt(tick.Unix(), 10), ttl, false)
+}
+
+func (l *ZookeeperLocker) AcquireRunning(name string, ttl time.Duration) (ILease, error) {
+	return l.claim(name, "running", ttl, true)
+}
+
+func (l *ZookeeperLocker) claim(name, node string, ttl time.Duration, release bool) (ILease, error) {
+	conn := l.client.GetConn()
+	parent := l.Prefix + "/" + name
+
+	if err := l.ensurePath(parent); err != nil {
+		return nil, err
+	}
+
+	path := parent + "/" + node
+
+	if _, err := conn.Create(path, nil, zk.FlagEphemeral, zk.WorldACL(zk.PermAll)); err != nil {
+		if errors.Is(err, zk.ErrNodeExists) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	_, stat, err := conn.Exists(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &zookeeperLease{locker: l, path: path, token: stat.Czxid, ttl: ttl, release: release}, nil
+}
+
+func (l *ZookeeperLocker) ensurePath(path string) error {
+	conn := l.client.GetConn()
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i := range parts {
+		p := "/" + strings.Join(parts[:i+1], "/")
+
+		if _, err := conn.Create(p, nil, 0, zk.WorldACL(zk.PermAll)); err != nil && !errors.Is(err, zk.ErrNodeExists) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *zookeeperLease) Token() int64 {
+	return l.token
+}
+
+// Renew checks the node still belongs to this session, the session keeps it alive.
+func (l *zookeeperLease) Renew(ttl time.Duration) error {
+	conn := l.locker.client.GetConn()
+	exists, stat, err := conn.Exists(l.path)
+
+	if err != nil {
+		return err
+	}
+
+	if !exists || stat.Czxid != l.token || stat.EphemeralOwner != conn.SessionID() {
+		return ErrLeaseLost
+	}
+
+	l.ttl = ttl
+	return nil
+}
+
+// Done deletes a tick node ttl later and a running node at once.
+func (l *zookeeperLease) Done() error {
+	if l.release {
+		return l.delete()
+	}
+
+	time.AfterFunc(l.ttl, func() {
+		_ = l.delete()
+	})
+
+	return nil
+}
+
+func (l *zookeeperLease) delete() error {
+	conn := l.locker.client.GetConn()
+	_, stat, err := conn.Exists(l.path)
+
+	if err != nil {
+		return err
+	}
+
+	if stat == nil || stat.Czxid != l.token {
+		return nil
+	}
+
+	if err = conn.Delete(l.path, stat.Version); errors.Is(err, zk.ErrNoNode) {
+		return nil
+	}
+
+	return err
+}
+
+func NewZookeeperLocker(client *zookeeper.Client) *ZookeeperLocker {
+	return &ZookeeperLocker{client: client, Prefix: "/golib/scheduler"}
+}
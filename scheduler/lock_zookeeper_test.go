@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"github.com/dylanpeng/golib/logger"
+	"github.com/dylanpeng/golib/zookeeper"
+	"github.com/go-zookeeper/zk"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newZookeeperClient(t *testing.T) *zookeeper.Client {
+	log, err := logger.NewLogger(&logger.Config{FilePath: filepath.Join(t.TempDir(), "zookeeper"), Level: "error", TimeFormat: time.RFC3339, MaxAgeDay: 1})
+
+	if err != nil {
+		t.Fatalf("new logger fail. | err: %s", err)
+	}
+
+	client, err := zookeeper.NewClient(&zookeeper.Config{Addrs: []string{"127.0.0.1:2181"}, Timeout: 5}, log, nil)
+
+	if err != nil {
+		t.Fatalf("new zookeeper client fail. | err: %s", err)
+	}
+
+	return client
+}
+
+func TestZookeeperLocker(t *testing.T) {
+	skipUnreachable(t, "127.0.0.1:2181")
+
+	client := newZookeeperClient(t)
+	defer client.Close()
+	locker := NewZookeeperLocker(client)
+	_, lease := testLockerClaim(t, locker)
+	path := lease.(*zookeeperLease).path
+
+	// the node was dropped and claimed again by another session, czxid and owner no longer match
+	other := newZookeeperClient(t)
+	defer other.Close()
+
+	if err := client.GetConn().Delete(path, -1); err != nil {
+		t.Fatalf("delete claim fail. | err: %s", err)
+	}
+
+	if _, err := other.GetConn().Create(path, nil, zk.FlagEphemeral, zk.WorldACL(zk.PermAll)); err != nil {
+		t.Fatalf("claim from another session fail. | err: %s", err)
+	}
+
+	if err := lease.Renew(5 * time.Second); err != ErrLeaseLost {
+		t.Fatalf("renewed a lease of another session. | err: %v", err)
+	}
+
+	// Done removes only its own node
+	lease.(*zookeeperLease).ttl = 0
+	_ = lease.Done()
+	time.Sleep(100 * time.Millisecond)
+
+	if exists, _, _ := other.GetConn().Exists(path); !exists {
+		t.Fatalf("done deleted the node of another session")
+	}
+}
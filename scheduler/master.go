@@ -1,35 +1,724 @@
 package scheduler
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dylanpeng/golib/logger"
 	"github.com/go-co-op/gocron"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+var (
+	ErrJobNotFound   = errors.New("scheduler job not found")
+	ErrJobExists     = errors.New("scheduler job already exists")
+	ErrMasterStopped = errors.New("scheduler master stopped")
+	ErrTickSkipped   = errors.New("scheduler tick skipped")
+)
+
+type jobEntry struct {
+	job            IJob
+	policy         *Policy
+	cronExpression string
+	cronJob        *gocron.Job
+	paused         bool
+	running        atomic.Bool
+	active         atomic.Int32
+	queue          sync.Mutex
+	waiting        atomic.Int32
+}
+
 type Master struct {
 	cronScheduler *gocron.Scheduler
-	providers     map[string]IProvider
+	jobs          map[string]*jobEntry
+	jobsLocker    sync.RWMutex
+	started       bool
+	locker        ILocker
+	logger        logger.ILogger
+	history       IHistoryStore
+	hooks         []IRunHook
+	instance      string
+	ctx           context.Context
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+	stopLocker    sync.Mutex
+	stopped       bool
 }
 
 func (m *Master) Start() {
-	for _, p := range m.providers {
-		m.cronScheduler.CronWithSeconds(p.GetCronExpression()).Do(p.Run)
+	m.jobsLocker.Lock()
+	defer m.jobsLocker.Unlock()
+
+	for _, entry := range m.jobs {
+		if entry.paused {
+			continue
+		}
+
+		if err := m.schedule(entry, entry.cronExpression); err != nil {
+			m.errorf("scheduler add job fail. | job: %s | err: %s", entry.job.GetName(), err)
+		}
 	}
 
+	m.started = true
 	m.cronScheduler.StartAsync()
 }
 
-func (m *Master) Stop() {
-	m.cronScheduler.Stop()
+// Stop stops scheduling, cancels the context of running jobs and waits for them until ctx is done.
+// It returns ctx.Err() when jobs were still running at the deadline. Triggers fail with ErrMasterStopped afterwards.
+func (m *Master) Stop(ctx context.Context) error {
+	m.stopLocker.Lock()
+	m.stopped = true
+	m.stopLocker.Unlock()
+
+	m.cancel()
+	done := make(chan struct{})
+
+	go func() {
+		m.cronScheduler.Stop()
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetLocker turns on distributed mode, each tick of a job runs on the one instance that claims it.
+// Call it before Start.
+func (m *Master) SetLocker(locker ILocker) {
+	m.locker = locker
+}
+
+func (m *Master) SetLogger(logger logger.ILogger) {
+	m.logger = logger
+}
+
+// SetHistoryStore replaces the default in memory history, nil turns recording off.
+func (m *Master) SetHistoryStore(store IHistoryStore) {
+	m.history = store
+}
+
+// SetInstance names this instance in run records, hostname-pid by default.
+func (m *Master) SetInstance(instance string) {
+	m.instance = instance
+}
+
+// AddHook registers a hook, call it before Start.
+func (m *Master) AddHook(hook IRunHook) {
+	m.hooks = append(m.hooks, hook)
+}
+
+// AddJob schedules a new job, right away when the master is started.
+func (m *Master) AddJob(j IJob) error {
+	m.jobsLocker.Lock()
+	defer m.jobsLocker.Unlock()
+
+	if _, ok := m.jobs[j.GetName()]; ok {
+		return ErrJobExists
+	}
+
+	entry := newJobEntry(j)
+
+	if m.started {
+		if err := m.schedule(entry, entry.cronExpression); err != nil {
+			return err
+		}
+	}
+
+	m.jobs[j.GetName()] = entry
+	return nil
+}
+
+// AddProvider schedules a legacy provider, see AdaptProvider.
+func (m *Master) AddProvider(p IProvider) error {
+	return m.AddJob(AdaptProvider(p))
 }
 
+// RemoveJob unschedules a job, a run in progress is not interrupted.
+func (m *Master) RemoveJob(name string) error {
+	m.jobsLocker.Lock()
+	defer m.jobsLocker.Unlock()
+
+	entry, ok := m.jobs[name]
+
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	m.unschedule(entry)
+	delete(m.jobs, name)
+	return nil
+}
+
+// PauseJob stops scheduling a job until ResumeJob, TriggerJob still runs it.
+func (m *Master) PauseJob(name string) error {
+	m.jobsLocker.Lock()
+	defer m.jobsLocker.Unlock()
+
+	entry, ok := m.jobs[name]
+
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	entry.paused = true
+	m.unschedule(entry)
+	return nil
+}
+
+func (m *Master) ResumeJob(name string) error {
+	m.jobsLocker.Lock()
+	defer m.jobsLocker.Unlock()
+
+	entry, ok := m.jobs[name]
+
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	if !entry.paused {
+		return nil
+	}
+
+	if m.started && entry.cronJob == nil {
+		if err := m.schedule(entry, entry.cronExpression); err != nil {
+			return err
+		}
+	}
+
+	entry.paused = false
+	return nil
+}
+
+// RescheduleJob replaces the cron expression of a job, the old schedule is kept when the new one is invalid.
+func (m *Master) RescheduleJob(name, cronExpression string) error {
+	m.jobsLocker.Lock()
+	defer m.jobsLocker.Unlock()
+
+	entry, ok := m.jobs[name]
+
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	if entry.cronJob == nil {
+		// validate on a throwaway scheduler, the job is scheduled by Start or ResumeJob
+		if _, err := gocron.NewScheduler(time.Local).CronWithSeconds(cronExpression).Do(func() {}); err != nil {
+			return err
+		}
+
+		entry.cronExpression = cronExpression
+		return nil
+	}
+
+	old := entry.cronJob
+
+	if err := m.schedule(entry, cronExpression); err != nil {
+		return err
+	}
+
+	m.cronScheduler.RemoveByReference(old)
+	return nil
+}
+
+// TriggerJob runs a job once in the background, outside of its schedule and even when paused.
+// The overlap policy and, in distributed mode, the claim of the current second are checked before
+// it returns, ErrTickSkipped means the run was dropped, e.g. because a scheduled run owns the second.
+func (m *Master) TriggerJob(name string) error {
+	m.jobsLocker.RLock()
+	entry, ok := m.jobs[name]
+	m.jobsLocker.RUnlock()
+
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	// Stop waits for the claim too
+	if !m.track() {
+		return ErrMasterStopped
+	}
+
+	tick := time.Now().Truncate(time.Second)
+
+	if !entry.reserve() {
+		m.wg.Done()
+		return fmt.Errorf("%w: previous run not finished", ErrTickSkipped)
+	}
+
+	// a queued trigger holds its claim while it waits, Renew drops it if the claim expired meanwhile
+	leases, err := m.claim(entry, tick)
+
+	if err != nil {
+		entry.unreserve()
+		m.wg.Done()
+		return err
+	}
+
+	go func() {
+		defer m.wg.Done()
+		m.runTick(entry, tick, leases, true)
+	}()
+
+	return nil
+}
+
+// GetHistory returns the latest limit runs of a job, newest first.
+func (m *Master) GetHistory(name string, limit int) ([]*RunRecord, error) {
+	m.jobsLocker.RLock()
+	_, ok := m.jobs[name]
+	m.jobsLocker.RUnlock()
+
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	return m.listHistory(name, limit)
+}
+
+// GetNextRun returns the next scheduled time of a job, zero before Start or while paused.
+func (m *Master) GetNextRun(name string) (time.Time, error) {
+	m.jobsLocker.RLock()
+	defer m.jobsLocker.RUnlock()
+
+	entry, ok := m.jobs[name]
+
+	if !ok {
+		return time.Time{}, ErrJobNotFound
+	}
+
+	return entry.nextRun(), nil
+}
+
+// GetJob returns the status of a job.
+func (m *Master) GetJob(name string) (*JobStatus, error) {
+	m.jobsLocker.RLock()
+	entry, ok := m.jobs[name]
+	var status *JobStatus
+
+	if ok {
+		status = entry.status()
+	}
+
+	m.jobsLocker.RUnlock()
+
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	m.fillLastRun(status)
+	return status, nil
+}
+
+// GetJobs returns the status of every job sorted by name.
+func (m *Master) GetJobs() []*JobStatus {
+	m.jobsLocker.RLock()
+	result := make([]*JobStatus, 0, len(m.jobs))
+
+	for _, entry := range m.jobs {
+		result = append(result, entry.status())
+	}
+
+	m.jobsLocker.RUnlock()
+
+	for _, status := range result {
+		m.fillLastRun(status)
+	}
+
+	sortJobStatus(result)
+	return result
+}
+
+func (m *Master) listHistory(name string, limit int) ([]*RunRecord, error) {
+	if m.history == nil {
+		return []*RunRecord{}, nil
+	}
+
+	return m.history.List(name, limit)
+}
+
+func (m *Master) fillLastRun(status *JobStatus) {
+	if records, err := m.listHistory(status.Name, 1); err == nil && len(records) > 0 {
+		status.LastRun = records[0]
+	}
+}
+
+// schedule registers the entry in gocron with cronExpression, the caller holds jobsLocker.
+func (m *Master) schedule(entry *jobEntry, cronExpression string) error {
+	cronJob, err := m.cronScheduler.CronWithSeconds(cronExpression).Do(m.execute, entry)
+
+	if err != nil {
+		return err
+	}
+
+	entry.cronJob = cronJob
+	entry.cronExpression = cronExpression
+	return nil
+}
+
+func (m *Master) unschedule(entry *jobEntry) {
+	if entry.cronJob != nil {
+		m.cronScheduler.RemoveByReference(entry.cronJob)
+		entry.cronJob = nil
+	}
+}
+
+func (m *Master) execute(entry *jobEntry) {
+	// cron fires on the second boundary of the local clock, so instances agree on the tick
+	tick := time.Now().Truncate(time.Second)
+
+	if !m.track() {
+		return
+	}
+
+	defer m.wg.Done()
+
+	if !entry.reserve() {
+		m.infof("scheduler skip tick, previous run not finished. | job: %s | overlap: %s | tick: %s", entry.job.GetName(), entry.policy.Overlap, tick)
+		return
+	}
+
+	m.runTick(entry, tick, nil, false)
+}
+
+// track adds a run to the wait group of Stop, false once Stop began so Add never races with Wait.
+func (m *Master) track() bool {
+	m.stopLocker.Lock()
+	defer m.stopLocker.Unlock()
+
+	if m.stopped {
+		return false
+	}
+
+	m.wg.Add(1)
+	return true
+}
+
+// claim takes the tick claim and, for OverlapSkip, the running lock. Without a locker it returns no leases.
+// ErrTickSkipped means another run owns the tick or the job.
+func (m *Master) claim(entry *jobEntry, tick time.Time) ([]ILease, error) {
+	if m.locker == nil {
+		return nil, nil
+	}
+
+	j := entry.job
+	ttl := getLockTtl(j)
+	lease, err := m.locker.Acquire(j.GetName(), tick, ttl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if lease == nil {
+		return nil, fmt.Errorf("%w: tick claimed by another run", ErrTickSkipped)
+	}
+
+	leases := []ILease{lease}
+
+	// skip has to hold across instances, the previous tick may still run elsewhere
+	if runningLocker, ok := m.locker.(IRunningLocker); ok && entry.policy.Overlap == OverlapSkip {
+		running, err := runningLocker.AcquireRunning(j.GetName(), ttl)
+
+		if err == nil && running == nil {
+			err = fmt.Errorf("%w: job running on another instance", ErrTickSkipped)
+		}
+
+		if err != nil {
+			m.release(j, leases)
+			return nil, err
+		}
+
+		leases = append(leases, running)
+	}
+
+	return leases, nil
+}
+
+func (m *Master) release(j IJob, leases []ILease) {
+	for _, lease := range leases {
+		if err := lease.Done(); err != nil {
+			m.errorf("scheduler release lock fail. | job: %s | err: %s", j.GetName(), err)
+		}
+	}
+}
+
+// runTick runs a reserved tick, claiming it first unless the caller already did.
+func (m *Master) runTick(entry *jobEntry, tick time.Time, leases []ILease, claimed bool) {
+	j := entry.job
+	entry.enter()
+	defer entry.leave()
+
+	if !claimed && m.ctx.Err() == nil {
+		var err error
+
+		if leases, err = m.claim(entry, tick); err != nil {
+			if errors.Is(err, ErrTickSkipped) {
+				m.infof("scheduler skip tick. | job: %s | tick: %s | reason: %s", j.GetName(), tick, err)
+			} else {
+				m.errorf("scheduler acquire lock fail. | job: %s | err: %s", j.GetName(), err)
+			}
+
+			return
+		}
+	}
+
+	defer m.release(j, leases)
+
+	// a queued tick may be released by Stop
+	if m.ctx.Err() != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	defer cancel()
+
+	if timeout := getTimeout(j); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if len(leases) == 0 {
+		m.record(ctx, entry, tick, 0)
+		return
+	}
+
+	lease := leases[0]
+	ttl := getLockTtl(j)
+
+	// a tick claimed after its ttl passed may already have run on an owner whose claim expired
+	if time.Since(tick) > ttl {
+		m.errorf("scheduler skip stale tick. | job: %s | tick: %s", j.GetName(), tick)
+		return
+	}
+
+	if err := lease.Renew(ttl); err != nil {
+		m.errorf("scheduler lease lost before run. | job: %s | token: %d | err: %s", j.GetName(), lease.Token(), err)
+		return
+	}
+
+	go m.keepAlive(ctx, cancel, j, ttl, leases...)
+	m.record(context.WithValue(ctx, fencingTokenKey{}, lease.Token()), entry, tick, lease.Token())
+}
+
+// record runs the job, then saves the run and reports it to the hooks.
+func (m *Master) record(ctx context.Context, entry *jobEntry, tick time.Time, token int64) {
+	name := entry.job.GetName()
+	entry.active.Add(1)
+	defer entry.active.Add(-1)
+
+	for _, hook := range m.hooks {
+		m.safeCall(name, "hook start", func() error {
+			hook.OnStart(name, tick)
+			return nil
+		})
+	}
+
+	r := &RunRecord{Job: name, Instance: m.instance, Tick: tick, StartTime: time.Now(), Status: RunStatusSuccess, FencingToken: token}
+	attempts, err := m.run(ctx, entry)
+	r.EndTime = time.Now()
+	r.Duration = r.EndTime.Sub(r.StartTime)
+	r.Attempts = attempts
+
+	if err != nil {
+		r.Status = RunStatusFailed
+		r.Error = err.Error()
+		r.Panic = errors.Is(err, ErrJobPanic)
+	}
+
+	if m.history != nil {
+		m.safeCall(name, "save run record", func() error {
+			return m.history.Save(r)
+		})
+	}
+
+	for _, hook := range m.hooks {
+		m.safeCall(name, "hook finish", func() error {
+			hook.OnFinish(r)
+			return nil
+		})
+	}
+}
+
+// safeCall runs a hook or history call, gocron has no panic handler so a panic there would crash the process.
+func (m *Master) safeCall(name, action string, call func() error) {
+	defer func() {
+		if r := recover(); r != nil {
+			m.errorf("scheduler %s fail. | job: %s | err: %s", action, name, recoverError(r))
+		}
+	}()
+
+	if err := call(); err != nil {
+		m.errorf("scheduler %s fail. | job: %s | err: %s", action, name, err)
+	}
+}
+
+// run runs the job and retries failures with backoff until the retries are used up or ctx is done.
+func (m *Master) run(ctx context.Context, entry *jobEntry) (attempts int, err error) {
+	j := entry.job
+
+	for attempts = 1; ; attempts++ {
+		if err = m.safeRun(ctx, j); err == nil {
+			return
+		}
+
+		m.errorf("scheduler job run fail. | job: %s | attempt: %d | err: %s", j.GetName(), attempts, err)
+
+		if attempts > entry.policy.RetryCount || ctx.Err() != nil {
+			return
+		}
+
+		timer := time.NewTimer(entry.policy.backoff(attempts))
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+func (m *Master) safeRun(ctx context.Context, j IJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoverError(r)
+		}
+	}()
+
+	return j.Run(ctx)
+}
+
+// keepAlive renews the claims while the job runs and cancels the job once one is lost.
+func (m *Master) keepAlive(ctx context.Context, cancel context.CancelFunc, j IJob, ttl time.Duration, leases ...ILease) {
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, lease := range leases {
+				if err := lease.Renew(ttl); err != nil {
+					m.errorf("scheduler renew lease fail. | job: %s | token: %d | err: %s", j.GetName(), lease.Token(), err)
+
+					if err == ErrLeaseLost {
+						cancel()
+						return
+					}
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Master) infof(format string, args ...any) {
+	if m.logger != nil {
+		m.logger.Infof(format, args...)
+	}
+}
+
+func (m *Master) errorf(format string, args ...any) {
+	if m.logger != nil {
+		m.logger.Errorf(format, args...)
+	}
+}
+
+// NewMaster schedules legacy providers, see AdaptProvider.
 func NewMaster(providers []IProvider) *Master {
+	jobs := make([]IJob, 0, len(providers))
+
+	for _, p := range providers {
+		jobs = append(jobs, AdaptProvider(p))
+	}
+
+	return NewJobMaster(jobs)
+}
+
+func NewJobMaster(jobs []IJob) *Master {
 	master := &Master{}
 	master.cronScheduler = gocron.NewScheduler(time.Local)
-	master.providers = make(map[string]IProvider, len(providers))
+	master.jobs = make(map[string]*jobEntry, len(jobs))
+	master.ctx, master.cancel = context.WithCancel(context.Background())
+	master.history = NewMemoryHistoryStore(DefaultHistoryLimit)
+	hostname, _ := os.Hostname()
+	master.instance = fmt.Sprintf("%s-%d", hostname, os.Getpid())
 
-	for _, p := range providers {
-		master.providers[p.GetName()] = p
+	for _, j := range jobs {
+		master.jobs[j.GetName()] = newJobEntry(j)
 	}
 
 	return master
 }
+
+func newJobEntry(j IJob) *jobEntry {
+	return &jobEntry{job: j, policy: getPolicy(j), cronExpression: j.GetCronExpression()}
+}
+
+// reserve takes the overlap slot of a tick without blocking, false means the tick is skipped.
+// A queued job keeps at most one tick waiting behind the running one, so a slow job can't pile up ticks.
+func (e *jobEntry) reserve() bool {
+	switch e.policy.Overlap {
+	case OverlapSkip:
+		return e.running.CompareAndSwap(false, true)
+	case OverlapQueue:
+		if e.waiting.Add(1) > 1 {
+			e.waiting.Add(-1)
+			return false
+		}
+	}
+
+	return true
+}
+
+// enter waits for the turn of a reserved tick.
+func (e *jobEntry) enter() {
+	if e.policy.Overlap == OverlapQueue {
+		e.queue.Lock()
+		e.waiting.Add(-1)
+	}
+}
+
+// leave frees the slot of a tick that entered.
+func (e *jobEntry) leave() {
+	switch e.policy.Overlap {
+	case OverlapSkip:
+		e.running.Store(false)
+	case OverlapQueue:
+		e.queue.Unlock()
+	}
+}
+
+// unreserve frees the slot of a tick that never entered.
+func (e *jobEntry) unreserve() {
+	switch e.policy.Overlap {
+	case OverlapSkip:
+		e.running.Store(false)
+	case OverlapQueue:
+		e.waiting.Add(-1)
+	}
+}
+
+func (e *jobEntry) nextRun() time.Time {
+	if e.cronJob == nil {
+		return time.Time{}
+	}
+
+	return e.cronJob.NextRun()
+}
+
+func (e *jobEntry) status() *JobStatus {
+	return &JobStatus{
+		Name:           e.job.GetName(),
+		CronExpression: e.cronExpression,
+		Paused:         e.paused,
+		Running:        e.active.Load() > 0,
+		NextRun:        e.nextRun(),
+	}
+}
@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// Overlap policies decide what happens when a tick fires while the previous run is still going.
+// Skip drops the tick, across instances when the locker is an IRunningLocker. Queue runs it after
+// the previous run, keeping at most one tick waiting and dropping the others.
+const (
+	OverlapAllow = "allow"
+	OverlapSkip  = "skip"
+	OverlapQueue = "queue"
+)
+
+const DefaultRetryInterval = time.Second
+
+var ErrJobPanic = errors.New("scheduler job panic")
+
+// Policy is the execution policy of a job. Failed runs are retried RetryCount times, waiting
+// RetryInterval first and doubling up to RetryMaxInterval. Panics are recovered and count as failures.
+type Policy struct {
+	Overlap          string
+	RetryCount       int
+	RetryInterval    time.Duration
+	RetryMaxInterval time.Duration
+}
+
+// IPolicyProvider lets a job set its policy, jobs without one allow overlap and are not retried.
+type IPolicyProvider interface {
+	GetPolicy() *Policy
+}
+
+func getPolicy(p any) *Policy {
+	if pp, ok := p.(IPolicyProvider); ok {
+		if policy := pp.GetPolicy(); policy != nil {
+			return policy
+		}
+	}
+
+	return &Policy{Overlap: OverlapAllow}
+}
+
+func (p *Policy) backoff(attempt int) time.Duration {
+	interval := p.RetryInterval
+
+	if interval <= 0 {
+		interval = DefaultRetryInterval
+	}
+
+	for i := 1; i < attempt; i++ {
+		interval *= 2
+
+		if p.RetryMaxInterval > 0 && interval >= p.RetryMaxInterval {
+			return p.RetryMaxInterval
+		}
+	}
+
+	return interval
+}
+
+func recoverError(recovered any) error {
+	return fmt.Errorf("%w: %v\n%s", ErrJobPanic, recovered, debug.Stack())
+}
@@ -0,0 +1,151 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type policyJob struct {
+	Provider
+	policy  *Policy
+	runs    int32
+	active  int32
+	maxSeen int32
+	do      func(run int32) error
+}
+
+func (j *policyJob) GetPolicy() *Policy {
+	return j.policy
+}
+
+func (j *policyJob) Run(ctx context.Context) error {
+	run := atomic.AddInt32(&j.runs, 1)
+	active := atomic.AddInt32(&j.active, 1)
+	defer atomic.AddInt32(&j.active, -1)
+
+	for {
+		seen := atomic.LoadInt32(&j.maxSeen)
+
+		if active <= seen || atomic.CompareAndSwapInt32(&j.maxSeen, seen, active) {
+			break
+		}
+	}
+
+	return j.do(run)
+}
+
+func runTicks(m *Master, name string, n int) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			m.execute(m.jobs[name])
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestMaster_Overlap(t *testing.T) {
+	cases := []struct {
+		overlap string
+		runs    int32
+		maxSeen int32
+	}{
+		{OverlapSkip, 1, 1},
+		{OverlapQueue, 2, 1},
+		{OverlapAllow, 5, 5},
+	}
+
+	for i, c := range cases {
+		job := &policyJob{Provider: Provider{Name: "job"}, policy: &Policy{Overlap: c.overlap}}
+		job.do = func(run int32) error {
+			time.Sleep(200 * time.Millisecond)
+			return nil
+		}
+
+		// a queued job keeps one tick waiting and drops the rest
+		runTicks(NewJobMaster([]IJob{job}), "job", 5)
+
+		if job.runs != c.runs || job.maxSeen != c.maxSeen {
+			t.Fatalf("case %d unexpected runs. | runs: %d | concurrent: %d", i, job.runs, job.maxSeen)
+		}
+	}
+}
+
+func TestMaster_OverlapSkipDistributed(t *testing.T) {
+	release := make(chan struct{})
+	job := &policyJob{Provider: Provider{Name: "job"}, policy: &Policy{Overlap: OverlapSkip}}
+	job.do = func(run int32) error {
+		<-release
+		return nil
+	}
+
+	locker := NewMemoryLocker()
+	a, b := NewJobMaster([]IJob{job}), NewJobMaster([]IJob{job})
+	a.SetLocker(locker)
+	b.SetLocker(locker)
+
+	done := make(chan struct{})
+
+	go func() {
+		a.execute(a.jobs["job"])
+		close(done)
+	}()
+
+	for atomic.LoadInt32(&job.runs) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the next tick claimed by another instance is skipped while the first one runs
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	b.execute(b.jobs["job"])
+
+	if runs := atomic.LoadInt32(&job.runs); runs != 1 {
+		t.Fatalf("job overlapped across instances. | runs: %d", runs)
+	}
+
+	close(release)
+	<-done
+
+	// the running lock is released with the run
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	b.execute(b.jobs["job"])
+
+	if runs := atomic.LoadInt32(&job.runs); runs != 2 {
+		t.Fatalf("running lock not released. | runs: %d", runs)
+	}
+}
+
+func TestMaster_Retry(t *testing.T) {
+	job := &policyJob{Provider: Provider{Name: "job"}, policy: &Policy{RetryCount: 3, RetryInterval: time.Millisecond}}
+	job.do = func(run int32) error {
+		switch run {
+		case 1:
+			panic("boom")
+		case 2:
+			return errors.New("fail")
+		}
+
+		return nil
+	}
+
+	runTicks(NewJobMaster([]IJob{job}), "job", 1)
+
+	if job.runs != 3 {
+		t.Fatalf("unexpected runs. | runs: %d", job.runs)
+	}
+
+	policy := &Policy{RetryInterval: time.Second, RetryMaxInterval: 3 * time.Second}
+
+	if policy.backoff(1) != time.Second || policy.backoff(2) != 2*time.Second || policy.backoff(3) != 3*time.Second {
+		t.Fatalf("unexpected backoff")
+	}
+}
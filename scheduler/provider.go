@@ -1,6 +1,9 @@
 package scheduler
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type IProvider interface {
 	GetName() string
@@ -12,6 +15,16 @@ type IProvider interface {
 type Provider struct {
 	Name           string `toml:"name" json:"name" yaml:"name"`
 	CronExpression string `toml:"cron_expression" json:"cron_expression" yaml:"cron_expression"`
+	// LockTtl is the distributed claim ttl in seconds, DefaultLockTtl when 0
+	LockTtl int `toml:"lock_ttl" json:"lock_ttl" yaml:"lock_ttl"`
+	// Timeout bounds a tick including retries in seconds, no timeout when 0
+	Timeout int `toml:"timeout" json:"timeout" yaml:"timeout"`
+	// Overlap is allow, skip or queue, allow when empty
+	Overlap string `toml:"overlap" json:"overlap" yaml:"overlap"`
+	// RetryCount retries failed runs, RetryInterval and RetryMaxInterval bound the backoff in seconds
+	RetryCount       int `toml:"retry_count" json:"retry_count" yaml:"retry_count"`
+	RetryInterval    int `toml:"retry_interval" json:"retry_interval" yaml:"retry_interval"`
+	RetryMaxInterval int `toml:"retry_max_interval" json:"retry_max_interval" yaml:"retry_max_interval"`
 }
 
 func (p *Provider) GetName() string {
@@ -22,6 +35,29 @@ func (p *Provider) GetCronExpression() string {
 	return p.CronExpression
 }
 
+func (p *Provider) GetLockTtl() time.Duration {
+	return time.Duration(p.LockTtl) * time.Second
+}
+
+func (p *Provider) GetTimeout() time.Duration {
+	return time.Duration(p.Timeout) * time.Second
+}
+
+func (p *Provider) GetPolicy() *Policy {
+	policy := &Policy{
+		Overlap:          p.Overlap,
+		RetryCount:       p.RetryCount,
+		RetryInterval:    time.Duration(p.RetryInterval) * time.Second,
+		RetryMaxInterval: time.Duration(p.RetryMaxInterval) * time.Second,
+	}
+
+	if policy.Overlap == "" {
+		policy.Overlap = OverlapAllow
+	}
+
+	return policy
+}
+
 func (p *Provider) String() string {
 	return fmt.Sprintf("%+v", *p)
 }
@@ -246,6 +246,10 @@ func (c *Client) Children(path string) (children []string, err error) {
 	return
 }
 
+func (c *Client) GetConn() *zk.Conn {
+	return c.conn
+}
+
 func (c *Client) Close() {
 	c.conn.Close()
 	c.wg.Wait()